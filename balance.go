@@ -0,0 +1,269 @@
+package gointervaltree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// WithAutoRebalance function returns an Option making the tree rebuild itself via Rebalance once its Height
+// exceeds threshold * log2(Len()). The check does not run on every insert, so its cost is amortized over
+// the inserts in between and the height may briefly exceed the bound. As before, Sort must be invoked after
+// adding intervals.
+func WithAutoRebalance(threshold float64) Option {
+	return func(config *treeConfig) {
+		config.rebalanceThreshold = threshold
+	}
+}
+
+// WithMaxDepth function returns an Option limiting the tree to depth nodes on any path from the root, the root
+// itself being at depth one. Intervals which would be placed below a node at that depth are kept in an overflow
+// list of the node instead, which is scanned linearly by queries. This bounds the recursion depth on adversarial
+// input at the cost of slower lookups in the affected nodes. A non-positive depth disables the limit.
+func WithMaxDepth(depth int) Option {
+	return func(config *treeConfig) {
+		config.maxDepth = depth
+	}
+}
+
+// CenterStrategy type defines a function choosing the center of a node over [min, max) during a bulk build
+// from the intervals the node receives. Centers outside [min+1, max) are clamped into it.
+type CenterStrategy func(min int, max int, intervals []Interval) int
+
+// WithCenterStrategy function returns an Option setting the CenterStrategy used by bulk builds, i.e. by
+// Rebalance and by AddIntervals, which then rebuilds the tree. By default AddIntervals inserts intervals
+// one by one with midpoint centers while Rebalance uses MedianCenter.
+func WithCenterStrategy(strategy CenterStrategy) Option {
+	return func(config *treeConfig) {
+		config.centerStrategy = strategy
+	}
+}
+
+// MedianCenter function is a CenterStrategy returning the median of the interval midpoints.
+func MedianCenter(min int, max int, intervals []Interval) int {
+	midpoints := make([]int, len(intervals))
+	for i, interval := range intervals {
+		midpoints[i] = interval.Start + (interval.End-interval.Start-1)/2
+	}
+	sort.Ints(midpoints)
+	return midpoints[len(midpoints)/2]
+}
+
+// minRebalanceCheckInterval constant defines the minimal number of inserts between two balance checks.
+const minRebalanceCheckInterval = 16
+
+// Height method returns the number of nodes on the longest path from the root to a leaf of the tree.
+func (tree *IntervalTree) Height() int {
+	height := 0
+	if tree.leftSubtree != nil {
+		height = tree.leftSubtree.Height()
+	}
+	if tree.rightSubtree != nil {
+		if right := tree.rightSubtree.Height(); right > height {
+			height = right
+		}
+	}
+	return height + 1
+}
+
+// IsBalanced method reports whether Height of the tree is at most factor * log2(Len), a cheap signal for whether
+// Rebalance is warranted. Since Height counts nodes, a tree consisting of its root only is always balanced.
+func (tree *IntervalTree) IsBalanced(factor float64) bool {
+	size := tree.Len()
+	if size < 1 {
+		size = 1
+	}
+	return float64(tree.Height()) <= math.Max(factor*math.Log2(float64(size)), 1)
+}
+
+// NodeCount method returns the number of nodes allocated in the tree, i.e. the root along with all its
+// subtrees, regardless of how many intervals each of them holds.
+func (tree *IntervalTree) NodeCount() int {
+	count := 1
+	if tree.leftSubtree != nil {
+		count += tree.leftSubtree.NodeCount()
+	}
+	if tree.rightSubtree != nil {
+		count += tree.rightSubtree.NodeCount()
+	}
+	return count
+}
+
+// Rebalance method rebuilds the tree choosing each node center as the median of the midpoints of the intervals
+// the node receives, which keeps the tree balanced regardless of how the intervals cluster, or via the strategy
+// set by WithCenterStrategy. Tombstoned intervals are dropped and the rebuilt tree is sorted.
+func (tree *IntervalTree) Rebalance() {
+	records := tree.records()
+	tree.reset()
+	tree.build(records)
+	tree.Sort()
+}
+
+// Prune method drops all subtrees holding no live intervals, e.g. after removals, setting the corresponding
+// child pointers to nil. Tombstoned intervals of the dropped subtrees are discarded with them, query results
+// are not affected.
+func (tree *IntervalTree) Prune() {
+	if tree.leftSubtree != nil {
+		if tree.leftSubtree.size == 0 {
+			tree.leftSubtree = nil
+		} else {
+			tree.leftSubtree.Prune()
+		}
+	}
+	if tree.rightSubtree != nil {
+		if tree.rightSubtree.size == 0 {
+			tree.rightSubtree = nil
+		} else {
+			tree.rightSubtree.Prune()
+		}
+	}
+}
+
+// reset method is a technical method clearing a node of all intervals and subtrees.
+func (tree *IntervalTree) reset() {
+	tree.singleInterval = nil
+	tree.split = false
+	tree.leftSubtree = nil
+	tree.rightSubtree = nil
+	tree.midSortedByStart = []*record{}
+	tree.midSortedByEnd = []*record{}
+	tree.overflow = nil
+	tree.index = nil
+	tree.tombstones = 0
+	tree.size = 0
+	tree.dirty = false
+	tree.unsorted = false
+}
+
+// build method is a technical method distributing records over an empty node and its newly created subtrees.
+func (tree *IntervalTree) build(records []*record) {
+	tree.buildWith(records, (*IntervalTree).chooseCenter)
+}
+
+// buildWith method is a technical method used inside build choosing the center of each split node via center.
+func (tree *IntervalTree) buildWith(records []*record, center func(node *IntervalTree, records []*record) int) {
+	tree.size = len(records)
+	if len(records) == 0 {
+		return
+	} else if len(records) == 1 {
+		tree.singleInterval = records[0]
+		return
+	}
+	tree.split = true
+	tree.dirty = true
+	tree.unsorted = true
+	tree.center = center(tree, records)
+	var left, right []*record
+	for _, r := range records {
+		if tree.overflows(r.Start, r.End) {
+			tree.overflow = append(tree.overflow, r)
+		} else if tree.config.upper(r.End) <= tree.center {
+			left = append(left, r)
+		} else if tree.config.lower(r.Start) > tree.center {
+			right = append(right, r)
+		} else {
+			tree.midSortedByStart = append(tree.midSortedByStart, r)
+			tree.midSortedByEnd = append(tree.midSortedByEnd, r)
+		}
+	}
+	if len(left) > 0 {
+		tree.leftSubtree = tree.newSubtree(tree.min, tree.center)
+		tree.leftSubtree.buildWith(left, center)
+	}
+	if len(right) > 0 {
+		tree.rightSubtree = tree.newSubtree(tree.center, tree.max)
+		tree.rightSubtree.buildWith(right, center)
+	}
+}
+
+// BuildFromBreakpoints function returns a new sorted tree over [first, last breakpoint) holding the given
+// intervals, whose nodes are centered at the median of the breakpoints strictly inside their bounds instead of
+// their midpoints, falling back to the median of the interval midpoints when there is none. Passing the interval
+// endpoints as breakpoints yields a tree balanced with respect to the data. The options are the same as for
+// NewIntervalTree, the intervals are interned and numbered as by AddInterval and can be undone via Undo in their
+// order, no auto-rebalancing check is made though since the tree is built balanced. An error is returned if there
+// are fewer than two breakpoints, they are not strictly ascending, or an interval is degenerate or out of bounds,
+// wrapping ErrDegenerateInterval or ErrOutOfBounds.
+func BuildFromBreakpoints(breakpoints []int, intervals []Interval, options ...Option) (*IntervalTree, error) {
+	if len(breakpoints) < 2 {
+		return nil, fmt.Errorf("at least two breakpoints are required, got %d", len(breakpoints))
+	}
+	for i := 1; i < len(breakpoints); i++ {
+		if breakpoints[i] <= breakpoints[i-1] {
+			return nil, fmt.Errorf("breakpoints must be strictly ascending, got %d after %d", breakpoints[i],
+				breakpoints[i-1])
+		}
+	}
+	tree := NewIntervalTree(breakpoints[0], breakpoints[len(breakpoints)-1], options...)
+	records := make([]*record, 0, len(intervals))
+	for _, interval := range intervals {
+		if err := tree.validate(interval.Start, interval.End); err != nil {
+			return nil, err
+		}
+		records = append(records, tree.newRecord(interval))
+	}
+	tree.buildWith(records, func(node *IntervalTree, records []*record) int {
+		low := sort.SearchInts(breakpoints, node.min+1)
+		high := sort.SearchInts(breakpoints, node.max)
+		if low < high {
+			return breakpoints[low+(high-low)/2]
+		}
+		return node.chooseCenter(records)
+	})
+	for _, r := range records {
+		tree.rememberInsert(r)
+	}
+	tree.Sort()
+	return tree, nil
+}
+
+// chooseCenter method returns the center for a node receiving records during a bulk build. For the default
+// median the median record always straddles the center, so that every build step makes progress, custom
+// centers are kept off min so that both subtrees are strictly narrower than the node.
+func (tree *IntervalTree) chooseCenter(records []*record) int {
+	intervals := make([]Interval, len(records))
+	for i, r := range records {
+		intervals[i] = Interval{Start: tree.config.lower(r.Start), End: tree.config.upper(r.End), Data: r.Data}
+	}
+	var center, lowest int
+	if tree.config.centerStrategy == nil {
+		center, lowest = MedianCenter(tree.min, tree.max, intervals), tree.min
+	} else {
+		center, lowest = tree.config.centerStrategy(tree.min, tree.max, intervals), tree.min+1
+	}
+	if center >= tree.max {
+		center = tree.max - 1
+	}
+	if center < lowest && lowest < tree.max {
+		center = lowest
+	} else if center < tree.min {
+		center = tree.min
+	}
+	return center
+}
+
+// maybeRebalance method is a technical method invoked after inserts into the root to check the balance
+// of the tree when WithAutoRebalance is set. The check runs every Len()/4 inserts or earlier if an insert
+// reached a depth beyond the bound, though never more often than every Len()/8 inserts, and at least
+// minRebalanceCheckInterval ones, so that both the checks and the rebuilds are amortized over a number of
+// inserts growing with the tree even when every insert lands deep, e.g. for intervals added in ascending order.
+func (tree *IntervalTree) maybeRebalance(depth int) {
+	threshold := tree.config.rebalanceThreshold
+	if threshold <= 0 {
+		return
+	}
+	tree.insertsSinceCheck++
+	if tree.insertsSinceCheck < minRebalanceCheckInterval || tree.insertsSinceCheck < tree.lenAtLastCheck/8 {
+		return
+	}
+	estimated := float64(tree.lenAtLastCheck + tree.insertsSinceCheck)
+	if tree.insertsSinceCheck < tree.lenAtLastCheck/4 && float64(depth) <= threshold*math.Log2(estimated) {
+		return
+	}
+	tree.insertsSinceCheck = 0
+	tree.lenAtLastCheck = tree.Len()
+	if float64(tree.Height()) > threshold*math.Log2(float64(tree.lenAtLastCheck)) {
+		tree.Rebalance()
+		tree.rebalances++
+	}
+}
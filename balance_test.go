@@ -0,0 +1,271 @@
+package gointervaltree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebalance(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1<<40)
+	assert.Equal(1, tree.Height())
+	var intervals []Interval
+	for i := 0; i < 1000; i++ {
+		intervals = append(intervals, Interval{i * 3, i*3 + 5, i})
+	}
+	tree.AddIntervals(intervals)
+	assert.True(tree.SoftRemove(0, 5, 0))
+	skewed := tree.Height()
+	tree.Rebalance()
+	assert.Less(tree.Height(), skewed)
+	assert.LessOrEqual(tree.Height(), 2*int(math.Log2(1000))+1)
+	assert.Equal(999, tree.Len())
+	assertSizes(tree, assert)
+	for q := 0; q < 3010; q++ {
+		var expected []Interval
+		for _, interval := range intervals[1:] {
+			if interval.Start <= q && q < interval.End {
+				expected = append(expected, interval)
+			}
+		}
+		assert.ElementsMatch(expected, tree.queryIntervals(q))
+	}
+	tree.AddInterval(5000, 6000, "new")
+	tree.Sort()
+	assert.Equal(1, len(tree.Query(5500)))
+}
+
+func TestNodeCount(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Equal(1, tree.NodeCount())
+	tree.AddInterval(10, 20, nil)
+	assert.Equal(1, tree.NodeCount())
+	tree.AddInterval(60, 70, nil)
+	assert.Equal(3, tree.NodeCount())
+	var intervals []Interval
+	for i := 0; i < 200; i++ {
+		intervals = append(intervals, Interval{1000 + i*4, 1000 + i*4 + 2, i})
+	}
+	skewed := NewIntervalTree(0, 1<<30)
+	skewed.AddIntervals(intervals)
+	balanced := NewIntervalTree(0, 1<<30)
+	balanced.AddIntervals(intervals)
+	balanced.Rebalance()
+	assert.Equal(skewed.Len(), balanced.Len())
+	assert.Less(balanced.NodeCount(), skewed.NodeCount())
+	assert.GreaterOrEqual(skewed.NodeCount(), skewed.Height())
+	assert.GreaterOrEqual(balanced.NodeCount(), balanced.Height())
+}
+
+func TestIsBalanced(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(NewIntervalTree(0, 100).IsBalanced(1))
+	var intervals []Interval
+	for i := 0; i < 1000; i++ {
+		intervals = append(intervals, Interval{i * 3, i*3 + 5, i})
+	}
+	skewed := NewIntervalTree(0, 1<<40)
+	for _, interval := range intervals {
+		skewed.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	skewed.Sort()
+	assert.Greater(float64(skewed.Height()), 2*math.Log2(1000))
+	assert.False(skewed.IsBalanced(2))
+	skewed.Rebalance()
+	assert.True(skewed.IsBalanced(2))
+	assert.False(skewed.IsBalanced(0.5))
+}
+
+func TestPrune(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range [][]int{{10, 20}, {5, 8}, {22, 24}, {40, 60}, {70, 80}, {90, 95}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	assert.NotNil(tree.leftSubtree)
+	tree.RemoveByCoords(10, 20)
+	tree.RemoveByCoords(5, 8)
+	tree.SoftRemove(22, 24, nil)
+	tree.RemoveByCoords(90, 95)
+	before := tree.NodeCount()
+	tree.Prune()
+	assert.Nil(tree.leftSubtree)
+	assert.NotNil(tree.rightSubtree)
+	assert.Less(tree.NodeCount(), before)
+	assert.Equal(2, tree.Len())
+	assertSizes(tree, assert)
+	assert.Equal(1, len(tree.Query(50)))
+	assert.Equal(1, len(tree.Query(75)))
+	assert.Empty(tree.Query(15))
+	tree.AddInterval(10, 20, nil)
+	tree.Sort()
+	assert.Equal(1, len(tree.Query(15)))
+}
+
+func TestWithAutoRebalance(t *testing.T) {
+	assert := assert.New(t)
+	const threshold = 3.0
+	plain := NewIntervalTree(0, 1<<40)
+	tree := NewIntervalTree(0, 1<<40, WithAutoRebalance(threshold))
+	random := rand.New(rand.NewSource(1))
+	for i := 1; i <= 4000; i++ {
+		start := random.Intn(4000)
+		end := start + 1 + random.Intn(3)
+		plain.AddInterval(start, end, nil)
+		tree.AddInterval(start, end, nil)
+		if i%100 == 0 {
+			assert.LessOrEqual(float64(tree.Height()), threshold*math.Log2(float64(tree.Len())))
+		}
+	}
+	plain.Sort()
+	tree.Sort()
+	assert.Greater(plain.Height(), 2*tree.Height())
+	assert.Equal(4000, tree.Len())
+	for q := 0; q < 4010; q++ {
+		assert.Equal(len(plain.Query(q)), len(tree.Query(q)))
+	}
+}
+
+func TestWithAutoRebalanceAscending(t *testing.T) {
+	assert := assert.New(t)
+	const threshold, count = 2.0, 20000
+	tree := NewIntervalTree(0, 1<<40, WithAutoRebalance(threshold))
+	rebalances := 0
+	for i := 0; i < count; i++ {
+		tree.AddInterval(10*i, 10*i+5, nil)
+		if tree.rebalances != rebalances {
+			rebalances = tree.rebalances
+			assert.LessOrEqual(float64(tree.Height()), threshold*math.Log2(float64(tree.Len())))
+		}
+	}
+	assert.Greater(rebalances, 0)
+	assert.LessOrEqual(float64(rebalances), math.Log(count)/math.Log(9.0/8))
+	assert.Equal(count, tree.Len())
+}
+
+func TestWithCenterStrategy(t *testing.T) {
+	assert := assert.New(t)
+	var intervals []Interval
+	for i := 0; i < 500; i++ {
+		intervals = append(intervals, Interval{1000 + i*2, 1000 + i*2 + 3, i})
+	}
+	plain := NewIntervalTree(0, 1<<30)
+	plain.AddIntervals(intervals)
+	median := NewIntervalTree(0, 1<<30, WithCenterStrategy(MedianCenter))
+	median.AddIntervals(intervals)
+	assert.Less(median.Height()*2, plain.Height())
+	calls := 0
+	leftmost := NewIntervalTree(0, 1<<30, WithCenterStrategy(func(min int, max int, intervals []Interval) int {
+		calls++
+		return min
+	}))
+	leftmost.AddIntervals(intervals)
+	assert.Greater(calls, 0)
+	for q := 990; q < 2010; q++ {
+		assert.Equal(len(plain.Query(q)), len(median.Query(q)))
+		assert.Equal(len(plain.Query(q)), len(leftmost.Query(q)))
+	}
+	assertSizes(median, assert)
+	assertSorted(median, assert)
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	assert := assert.New(t)
+	plain := NewIntervalTree(0, 1<<40)
+	capped := NewIntervalTree(0, 1<<40, WithMaxDepth(4))
+	var intervals []Interval
+	for i := 0; i < 300; i++ {
+		intervals = append(intervals, Interval{i, i + 1 + i%7, i})
+	}
+	for i := 1; i < 40; i++ {
+		intervals = append(intervals, Interval{i, 1 << i, -i})
+	}
+	plain.AddIntervals(intervals)
+	capped.AddIntervals(intervals)
+	assert.Greater(plain.Height(), 4)
+	assert.Equal(4, capped.Height())
+	assert.Equal(len(intervals), capped.Len())
+	_, _, misplaced := capped.FirstMisplaced()
+	assert.False(misplaced)
+	check := func() {
+		assertSizes(capped, assert)
+		assert.LessOrEqual(capped.Height(), 4)
+		for q := 0; q < 320; q++ {
+			assert.ElementsMatch(plain.queryIntervals(q), capped.queryIntervals(q))
+			assert.ElementsMatch(plain.QueryRange(q, q+5), capped.QueryRange(q, q+5))
+			assert.Equal(plain.ExistsInRange(q, q+2), capped.ExistsInRange(q, q+2))
+		}
+		assert.ElementsMatch(plain.IterSorted(), capped.IterSorted())
+	}
+	check()
+	assert.True(capped.ContainsInterval(10, 14, 10))
+	assert.True(plain.SoftRemove(10, 14, 10))
+	assert.True(capped.SoftRemove(10, 14, 10))
+	assert.False(capped.ContainsInterval(10, 14, 10))
+	assert.Equal(1, plain.RemoveByCoords(20, 27))
+	assert.Equal(1, capped.RemoveByCoords(20, 27))
+	check()
+	capped.Compact()
+	check()
+	capped.Rebalance()
+	check()
+	assert.True(capped.Undo())
+	assert.True(plain.Undo())
+	check()
+}
+
+func TestBuildFromBreakpoints(t *testing.T) {
+	assert := assert.New(t)
+	var intervals []Interval
+	for i := 0; i < 400; i++ {
+		intervals = append(intervals, Interval{1000 + i*3, 1000 + i*3 + 1 + i%5, i})
+	}
+	intervals = append(intervals, Interval{0, 1 << 30, "whole"})
+	plain := NewIntervalTree(0, 1<<30)
+	plain.AddIntervals(intervals)
+	breakpoints := plain.Breakpoints()
+	built, err := BuildFromBreakpoints(breakpoints, intervals)
+	assert.NoError(err)
+	assert.Equal(plain.Len(), built.Len())
+	assert.Less(built.Height(), plain.Height())
+	assert.LessOrEqual(built.Height(), 2*int(math.Log2(float64(len(breakpoints))))+1)
+	assertSizes(built, assert)
+	assertSorted(built, assert)
+	for q := 990; q < 2220; q++ {
+		assert.ElementsMatch(plain.queryIntervals(q), built.queryIntervals(q))
+	}
+	_, err = BuildFromBreakpoints([]int{5}, nil)
+	assert.Error(err)
+	_, err = BuildFromBreakpoints([]int{0, 10, 10}, nil)
+	assert.Error(err)
+	_, err = BuildFromBreakpoints([]int{0, 10}, []Interval{{5, 11, nil}})
+	assert.ErrorIs(err, ErrOutOfBounds)
+	_, err = BuildFromBreakpoints([]int{0, 10}, []Interval{{5, 5, nil}})
+	assert.ErrorIs(err, ErrDegenerateInterval)
+	empty, err := BuildFromBreakpoints([]int{0, 10}, nil)
+	assert.NoError(err)
+	assert.Equal(0, empty.Len())
+}
+
+func TestBuildFromBreakpointsOptions(t *testing.T) {
+	assert := assert.New(t)
+	intervals := []Interval{{10, 30, []int{1}}, {40, 60, "b"}, {20, 50, []int{1}}}
+	tree, err := BuildFromBreakpoints([]int{0, 25, 50, 100}, intervals, WithDataInterning(), WithInsertionOrder())
+	assert.NoError(err)
+	var shared [][]int
+	for _, interval := range tree.queryIntervals(25) {
+		shared = append(shared, interval.Data.([]int))
+	}
+	if assert.Equal(2, len(shared)) {
+		assert.Same(&shared[0][0], &shared[1][0])
+	}
+	assert.Equal(intervals, tree.IterInsertionOrder())
+	assert.True(tree.Undo())
+	assert.Equal(intervals[:2], tree.IterInsertionOrder())
+	assert.ElementsMatch([]interface{}{[]interface{}{40, 60, "b"}}, tree.Query(45))
+}
@@ -0,0 +1,115 @@
+package gointervaltree
+
+import "sort"
+
+// IntersectRanges method returns, for each pair of overlapping intervals a from the tree and b from other,
+// the intersection range [max(a.Start, b.Start), min(a.End, b.End)) with data being [2]interface{}{a.Data, b.Data}.
+// Empty intersections are skipped, the result is sorted by start, then by end.
+func (tree *IntervalTree) IntersectRanges(other *IntervalTree) []Interval {
+	var result []Interval
+	for _, a := range tree.records() {
+		for _, b := range other.queryRange(a.Start, a.End) {
+			start, end := a.Start, a.End
+			if b.Start > start {
+				start = b.Start
+			}
+			if b.End < end {
+				end = b.End
+			}
+			if start < end {
+				result = append(result, Interval{Start: start, End: end, Data: [2]interface{}{a.Data, b.Data}})
+			}
+		}
+	}
+	sortIntervals(result)
+	return result
+}
+
+// OverlapMatrix method returns the sparse matrix of overlaps between the tree and other, mapping the position of
+// every interval of the tree in IterSorted to the ascending positions of the intervals of other in its IterSorted
+// which overlap it. Intervals of the tree overlapping nothing have no entry.
+func (tree *IntervalTree) OverlapMatrix(other *IntervalTree) map[int][]int {
+	result := make(map[int][]int)
+	positions := make(map[*record]int)
+	for j, b := range other.sortedRecords() {
+		positions[b] = j
+	}
+	for i, a := range tree.sortedRecords() {
+		var row []int
+		for _, b := range other.queryRange(a.Start, a.End) {
+			row = append(row, positions[b])
+		}
+		if len(row) > 0 {
+			sort.Ints(row)
+			result[i] = row
+		}
+	}
+	return result
+}
+
+// sortedRecords method is a technical method returning all live records maintained in the tree in IterSorted order.
+func (tree *IntervalTree) sortedRecords() []*record {
+	records := tree.records()
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Start != records[j].Start {
+			return records[i].Start < records[j].Start
+		}
+		return records[i].End < records[j].End
+	})
+	return records
+}
+
+// addUnique method is a technical method adding an interval to the tree unless an equal one is already there.
+func (tree *IntervalTree) addUnique(interval Interval) {
+	if !tree.ContainsInterval(interval.Start, interval.End, interval.Data) {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+}
+
+// Union method returns a new sorted tree containing every (start, end, data) element present in the tree
+// or in other, equal elements (data compared via reflect.DeepEqual) are kept once. The new tree spans
+// the bounds of both trees.
+func (tree *IntervalTree) Union(other *IntervalTree) *IntervalTree {
+	min, max := tree.min, tree.max
+	if other.min < min {
+		min = other.min
+	}
+	if other.max > max {
+		max = other.max
+	}
+	result := tree.derive(min, max)
+	for _, interval := range tree.intervals() {
+		result.addUnique(interval)
+	}
+	for _, interval := range other.intervals() {
+		result.addUnique(interval)
+	}
+	result.Sort()
+	return result
+}
+
+// Intersect method returns a new sorted tree over the bounds of the tree containing every (start, end, data)
+// element present in both the tree and other, data compared via reflect.DeepEqual.
+func (tree *IntervalTree) Intersect(other *IntervalTree) *IntervalTree {
+	result := tree.derive(tree.min, tree.max)
+	for _, interval := range tree.intervals() {
+		if other.ContainsInterval(interval.Start, interval.End, interval.Data) {
+			result.addUnique(interval)
+		}
+	}
+	result.Sort()
+	return result
+}
+
+// Subtract method returns a new sorted tree over the bounds of the tree containing every (start, end, data)
+// element of the tree not present in other, data compared via reflect.DeepEqual.
+func (tree *IntervalTree) Subtract(other *IntervalTree) *IntervalTree {
+	result := tree.derive(tree.min, tree.max)
+	for _, interval := range tree.intervals() {
+		if !other.ContainsInterval(interval.Start, interval.End, interval.Data) {
+			result.addUnique(interval)
+		}
+	}
+	result.Sort()
+	return result
+}
@@ -0,0 +1,83 @@
+package gointervaltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersectRanges(t *testing.T) {
+	assert := assert.New(t)
+	first := NewIntervalTree(0, 100)
+	first.AddInterval(10, 30, "a1")
+	first.AddInterval(40, 60, "a2")
+	first.AddInterval(80, 90, "a3")
+	first.Sort()
+	second := NewIntervalTree(0, 100)
+	second.AddInterval(20, 45, "b1")
+	second.AddInterval(50, 55, "b2")
+	second.AddInterval(90, 95, "b3")
+	second.Sort()
+	assert.Equal([]Interval{
+		{20, 30, [2]interface{}{"a1", "b1"}},
+		{40, 45, [2]interface{}{"a2", "b1"}},
+		{50, 55, [2]interface{}{"a2", "b2"}},
+	}, first.IntersectRanges(second))
+	assert.Empty(first.IntersectRanges(NewIntervalTree(0, 100)))
+}
+
+func TestOverlapMatrix(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, "a0")
+	tree.AddInterval(40, 60, "a1")
+	tree.AddInterval(0, 5, "a2")
+	tree.AddInterval(10, 30, "a3")
+	tree.Sort()
+	other := NewIntervalTree(0, 100)
+	other.AddInterval(25, 45, "b0")
+	other.AddInterval(5, 10, "b1")
+	other.AddInterval(55, 90, "b2")
+	other.AddInterval(0, 100, "b3")
+	other.Sort()
+	matrix := tree.OverlapMatrix(other)
+	as, bs := tree.IterSorted(), other.IterSorted()
+	expected := make(map[int][]int)
+	for i, a := range as {
+		for j, b := range bs {
+			if a.Start < b.End && b.Start < a.End {
+				expected[i] = append(expected[i], j)
+			}
+		}
+	}
+	assert.Equal(expected, matrix)
+	assert.Equal(map[int][]int{0: {0}, 1: {0, 2}, 2: {0, 2}, 3: {0, 2, 3}}, matrix)
+	assert.Empty(tree.OverlapMatrix(NewIntervalTree(0, 100)))
+}
+
+func TestSetOperations(t *testing.T) {
+	assert := assert.New(t)
+	first := NewIntervalTree(0, 100)
+	first.AddInterval(10, 20, "a")
+	first.AddInterval(10, 20, "b")
+	first.AddInterval(30, 60, []int{1, 2})
+	first.AddInterval(70, 80, nil)
+	first.Sort()
+	second := NewIntervalTree(0, 200)
+	second.AddInterval(10, 20, "b")
+	second.AddInterval(30, 60, []int{1, 2})
+	second.AddInterval(30, 60, []int{1, 3})
+	second.AddInterval(150, 160, "c")
+	second.AddInterval(150, 160, "c")
+	second.Sort()
+	union := first.Union(second)
+	assert.Equal(0, union.min)
+	assert.Equal(200, union.max)
+	assert.ElementsMatch([]Interval{{10, 20, "a"}, {10, 20, "b"}, {30, 60, []int{1, 2}}, {30, 60, []int{1, 3}},
+		{70, 80, nil}, {150, 160, "c"}}, union.intervals())
+	assert.ElementsMatch([]Interval{{10, 20, "b"}, {30, 60, []int{1, 2}}}, first.Intersect(second).intervals())
+	assert.ElementsMatch([]Interval{{10, 20, "a"}, {70, 80, nil}}, first.Subtract(second).intervals())
+	assert.ElementsMatch([]Interval{{30, 60, []int{1, 3}}, {150, 160, "c"}}, second.Subtract(first).intervals())
+	assert.Empty(first.Subtract(first).intervals())
+	assert.Equal(4, first.Len())
+}
@@ -0,0 +1,70 @@
+package gointervaltree
+
+import "reflect"
+
+// CompositeIntervalTree struct defines a read-only view over several trees answering queries with the union of
+// their results, so that the trees can be overlaid without copying their intervals. Elements equal in start, end
+// and data (compared via reflect.DeepEqual) are reported once, as by Union. Changes of the underlying trees become
+// visible through the view once the trees are sorted.
+type CompositeIntervalTree struct {
+	trees []*IntervalTree
+}
+
+// Compose function returns a CompositeIntervalTree over the given trees.
+func Compose(trees ...*IntervalTree) *CompositeIntervalTree {
+	composite := &CompositeIntervalTree{trees: make([]*IntervalTree, len(trees))}
+	copy(composite.trees, trees)
+	return composite
+}
+
+// union method is a technical method collecting the records returned by collect for every underlying tree,
+// in the order of the trees, dropping elements equal to an earlier one.
+func (composite *CompositeIntervalTree) union(collect func(tree *IntervalTree) []*record) []Interval {
+	var result []Interval
+	seen := make(map[[2]int][]interface{})
+	for _, tree := range composite.trees {
+		for _, r := range collect(tree) {
+			key := [2]int{r.Start, r.End}
+			duplicate := false
+			for _, data := range seen[key] {
+				if reflect.DeepEqual(data, r.Data) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				seen[key] = append(seen[key], r.Data)
+				result = append(result, r.Interval)
+			}
+		}
+	}
+	return result
+}
+
+// Query method returns the distinct intervals of the underlying trees which overlap given point.
+func (composite *CompositeIntervalTree) Query(x int) []Interval {
+	return composite.union(func(tree *IntervalTree) []*record {
+		return tree.query(x)
+	})
+}
+
+// QueryRange method returns the distinct intervals of the underlying trees which overlap given range [low, high).
+func (composite *CompositeIntervalTree) QueryRange(low int, high int) []Interval {
+	if (high - low) <= 0 {
+		return nil
+	}
+	return composite.union(func(tree *IntervalTree) []*record {
+		return tree.queryRange(low, high)
+	})
+}
+
+// Iter method returns the distinct intervals of all underlying trees.
+func (composite *CompositeIntervalTree) Iter() []Interval {
+	return composite.union((*IntervalTree).records)
+}
+
+// Len method represents the number of distinct intervals of all underlying trees, which are all visited to
+// find the duplicates.
+func (composite *CompositeIntervalTree) Len() int {
+	return len(composite.Iter())
+}
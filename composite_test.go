@@ -0,0 +1,40 @@
+package gointervaltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompositeIntervalTree(t *testing.T) {
+	assert := assert.New(t)
+	first := NewIntervalTree(0, 100)
+	first.AddInterval(10, 30, "a")
+	first.AddInterval(20, 60, "shared")
+	first.AddInterval(70, 80, []int{1})
+	first.Sort()
+	second := NewIntervalTree(50, 200)
+	second.AddInterval(60, 90, "b")
+	second.AddInterval(150, 180, "c")
+	second.Sort()
+	third := NewIntervalTree(0, 100)
+	third.AddInterval(20, 60, "shared")
+	third.AddInterval(20, 60, "other")
+	third.AddInterval(70, 80, []int{1})
+	third.Sort()
+	composite := Compose(first, second, third)
+	assert.ElementsMatch([]Interval{{10, 30, "a"}, {20, 60, "shared"}, {20, 60, "other"}}, composite.Query(25))
+	assert.ElementsMatch([]Interval{{70, 80, []int{1}}, {60, 90, "b"}}, composite.Query(75))
+	assert.Equal([]Interval{{150, 180, "c"}}, composite.Query(160))
+	assert.ElementsMatch([]Interval{{20, 60, "shared"}, {20, 60, "other"}, {60, 90, "b"}, {70, 80, []int{1}}},
+		composite.QueryRange(55, 75))
+	assert.Empty(composite.QueryRange(55, 55))
+	assert.Equal(6, composite.Len())
+	assert.ElementsMatch([]Interval{{10, 30, "a"}, {20, 60, "shared"}, {70, 80, []int{1}}, {60, 90, "b"},
+		{150, 180, "c"}, {20, 60, "other"}}, composite.Iter())
+	assert.Equal(3, first.Len())
+	second.AddInterval(100, 120, "d")
+	second.Sort()
+	assert.Equal([]Interval{{100, 120, "d"}}, composite.Query(110))
+	assert.Equal(0, Compose().Len())
+}
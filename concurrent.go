@@ -0,0 +1,154 @@
+package gointervaltree
+
+import (
+	"log"
+	"sort"
+	"sync"
+)
+
+// ConcurrentIntervalTree struct defines a concurrency-safe interval tree which splits [min, max) into shards,
+// each guarded by its own lock, so that inserts into disjoint coordinate regions proceed concurrently.
+// Intervals crossing shard boundaries are kept in a separate spanning tree with a lock of its own.
+// Unlike IntervalTree, no explicit Sort is required, shards are sorted lazily on the first query after insert.
+type ConcurrentIntervalTree struct {
+	min      int
+	max      int
+	bounds   []int
+	shards   []*lockedTree
+	spanning *lockedTree
+}
+
+// lockedTree struct defines an IntervalTree guarded by a read-write lock keeping track of pending sorting.
+type lockedTree struct {
+	mu    sync.RWMutex
+	tree  *IntervalTree
+	dirty bool
+}
+
+// NewConcurrentIntervalTree method instantiates an instance of ConcurrentIntervalTree struct splitting [min, max)
+// into the given number of equally sized shards.
+func NewConcurrentIntervalTree(min int, max int, shards int) (tree *ConcurrentIntervalTree) {
+	if !(min < max) {
+		log.Panic("AssertionError: interval tree start must be numerically less than its end")
+	}
+	if shards < 1 {
+		log.Panic("AssertionError: interval tree must have at least one shard")
+	}
+	if shards > max-min {
+		shards = max - min
+	}
+	tree = new(ConcurrentIntervalTree)
+	tree.min = min
+	tree.max = max
+	tree.bounds = make([]int, shards+1)
+	tree.shards = make([]*lockedTree, shards)
+	for i := 0; i <= shards; i++ {
+		tree.bounds[i] = min + int(int64(max-min)*int64(i)/int64(shards))
+	}
+	for i := 0; i < shards; i++ {
+		tree.shards[i] = &lockedTree{tree: NewIntervalTree(tree.bounds[i], tree.bounds[i+1])}
+	}
+	tree.spanning = &lockedTree{tree: NewIntervalTree(min, max)}
+	return tree
+}
+
+// shardIndex method returns the index of the shard containing point x, -1 if x lies outside [min, max).
+func (tree *ConcurrentIntervalTree) shardIndex(x int) int {
+	if x < tree.min || x >= tree.max {
+		return -1
+	}
+	return sort.SearchInts(tree.bounds[1:], x+1)
+}
+
+// AddInterval method adds intervals to the tree locking only the shard the interval falls into.
+func (tree *ConcurrentIntervalTree) AddInterval(start int, end int, data interface{}) {
+	if (end - start) <= 0 {
+		return
+	}
+	target := tree.spanning
+	if first := tree.shardIndex(start); first >= 0 && first == tree.shardIndex(end-1) {
+		target = tree.shards[first]
+	}
+	target.mu.Lock()
+	target.tree.AddInterval(start, end, data)
+	target.dirty = true
+	target.mu.Unlock()
+}
+
+// read method runs fn under the read lock of the tree sorting it beforehand if there were inserts since
+// the last sort.
+func (locked *lockedTree) read(fn func(tree *IntervalTree)) {
+	locked.mu.RLock()
+	for locked.dirty {
+		locked.mu.RUnlock()
+		locked.mu.Lock()
+		if locked.dirty {
+			locked.tree.Sort()
+			locked.dirty = false
+		}
+		locked.mu.Unlock()
+		locked.mu.RLock()
+	}
+	fn(locked.tree)
+	locked.mu.RUnlock()
+}
+
+// Query method returns all intervals in the tree which overlap given point, i.e. all intervals,
+// for which (start <= x < end).
+func (tree *ConcurrentIntervalTree) Query(x int) []Interval {
+	var result []Interval
+	index := tree.shardIndex(x)
+	if index < 0 {
+		return result
+	}
+	collect := func(t *IntervalTree) {
+		for _, r := range t.query(x) {
+			result = append(result, r.Interval)
+		}
+	}
+	tree.shards[index].read(collect)
+	tree.spanning.read(collect)
+	return result
+}
+
+// QueryRange method returns all intervals in the tree which overlap given range [low, high),
+// i.e. all intervals for which (start < high && low < end).
+func (tree *ConcurrentIntervalTree) QueryRange(low int, high int) []Interval {
+	var result []Interval
+	collect := func(t *IntervalTree) {
+		result = append(result, t.QueryRange(low, high)...)
+	}
+	for i, shard := range tree.shards {
+		if tree.bounds[i] < high && low < tree.bounds[i+1] {
+			shard.read(collect)
+		}
+	}
+	tree.spanning.read(collect)
+	return result
+}
+
+// Len method represents the number of intervals maintained in the tree.
+func (tree *ConcurrentIntervalTree) Len() int {
+	size := 0
+	count := func(t *IntervalTree) {
+		size += t.Len()
+	}
+	for _, shard := range tree.shards {
+		shard.read(count)
+	}
+	tree.spanning.read(count)
+	return size
+}
+
+// Iter method returns a slice of all intervals maintained in the tree.
+func (tree *ConcurrentIntervalTree) Iter() []Interval {
+	var result []Interval
+	collect := func(t *IntervalTree) {
+		result = append(result, t.intervals()...)
+	}
+	for _, shard := range tree.shards {
+		shard.read(collect)
+	}
+	tree.spanning.read(collect)
+	return result
+}
@@ -0,0 +1,62 @@
+package gointervaltree
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentIntervalTree(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewConcurrentIntervalTree(0, 1000, 8)
+	tree.AddInterval(100, 200, "a")
+	tree.AddInterval(120, 130, "b")
+	tree.AddInterval(10, 990, "c")
+	tree.AddInterval(5, 5, "empty")
+	assert.ElementsMatch([]Interval{{100, 200, "a"}, {120, 130, "b"}, {10, 990, "c"}}, tree.Query(125))
+	assert.ElementsMatch([]Interval{{10, 990, "c"}}, tree.Query(500))
+	assert.Empty(tree.Query(995))
+	assert.Empty(tree.Query(-1))
+	assert.Empty(tree.Query(1000))
+	assert.ElementsMatch([]Interval{{100, 200, "a"}, {10, 990, "c"}}, tree.QueryRange(150, 300))
+	assert.Equal(3, tree.Len())
+	assert.Equal(3, len(tree.Iter()))
+	small := NewConcurrentIntervalTree(0, 3, 10)
+	small.AddInterval(0, 3, nil)
+	assert.Equal(1, len(small.Query(2)))
+}
+
+func TestConcurrentIntervalTreeStress(t *testing.T) {
+	assert := assert.New(t)
+	const workers = 8
+	const perWorker = 500
+	tree := NewConcurrentIntervalTree(0, workers*perWorker*10, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			base := w * perWorker * 10
+			for i := 0; i < perWorker; i++ {
+				tree.AddInterval(base+i*10, base+i*10+5, w)
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			base := w * perWorker * 10
+			for i := 0; i < perWorker; i++ {
+				tree.Query(base + i*10)
+			}
+		}(w)
+	}
+	wg.Wait()
+	assert.Equal(workers*perWorker, tree.Len())
+	for w := 0; w < workers; w++ {
+		base := w * perWorker * 10
+		for i := 0; i < perWorker; i++ {
+			assert.Equal([]Interval{{base + i*10, base + i*10 + 5, w}}, tree.Query(base+i*10+2))
+			assert.Empty(tree.Query(base + i*10 + 7))
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package gointervaltree
+
+import "errors"
+
+// ErrOutOfBounds error is returned for an interval which does not lie within [min, max) of the tree.
+var ErrOutOfBounds = errors.New("interval is out of the tree bounds")
+
+// ErrDegenerateInterval error is returned for an interval covering no points, e.g. start >= end in the default mode.
+var ErrDegenerateInterval = errors.New("interval is degenerate")
@@ -0,0 +1,109 @@
+package gointervaltree
+
+// FlatIntervalTree struct defines a read-only copy of an IntervalTree laid out in parallel arrays indexed by node,
+// so that queries walk the arrays by index instead of following pointers. Node zero is the root, a child index
+// of -1 stands for a missing subtree and the mid intervals of node i occupy [midOffsets[i], midOffsets[i+1])
+// of byStart and byEnd.
+type FlatIntervalTree struct {
+	centers        []int
+	lefts          []int
+	rights         []int
+	midOffsets     []int
+	byStart        []Interval
+	byEnd          []Interval
+	linearOffsets  []int
+	linear         []Interval
+	config         treeConfig
+	intervalsCount int
+}
+
+// Flatten method sorts the tree and returns a FlatIntervalTree holding its structure and live intervals.
+// The flat tree is not affected by later changes of the tree.
+func (tree *IntervalTree) Flatten() *FlatIntervalTree {
+	tree.Sort()
+	flat := &FlatIntervalTree{config: *tree.config}
+	flat.add(tree)
+	flat.midOffsets = append(flat.midOffsets, len(flat.byStart))
+	flat.linearOffsets = append(flat.linearOffsets, len(flat.linear))
+	return flat
+}
+
+// add method is a technical method used inside Flatten appending a node and its subtrees in pre-order,
+// it returns the index of the node. Single intervals and overflow intervals are kept in the linearly
+// scanned list of the node.
+func (flat *FlatIntervalTree) add(tree *IntervalTree) int {
+	index := len(flat.centers)
+	flat.centers = append(flat.centers, tree.center)
+	flat.lefts = append(flat.lefts, -1)
+	flat.rights = append(flat.rights, -1)
+	flat.midOffsets = append(flat.midOffsets, len(flat.byStart))
+	flat.linearOffsets = append(flat.linearOffsets, len(flat.linear))
+	if !tree.split {
+		if tree.singleInterval != nil && !tree.singleInterval.tombstone {
+			flat.linear = append(flat.linear, tree.singleInterval.Interval)
+			flat.intervalsCount++
+		}
+		return index
+	}
+	for _, r := range tree.overflow {
+		if !r.tombstone {
+			flat.linear = append(flat.linear, r.Interval)
+			flat.intervalsCount++
+		}
+	}
+	for _, r := range tree.midSortedByStart {
+		if !r.tombstone {
+			flat.byStart = append(flat.byStart, r.Interval)
+			flat.intervalsCount++
+		}
+	}
+	for _, r := range tree.midSortedByEnd {
+		if !r.tombstone {
+			flat.byEnd = append(flat.byEnd, r.Interval)
+		}
+	}
+	if tree.leftSubtree != nil {
+		flat.lefts[index] = flat.add(tree.leftSubtree)
+	}
+	if tree.rightSubtree != nil {
+		flat.rights[index] = flat.add(tree.rightSubtree)
+	}
+	return index
+}
+
+// Len method represents the number of intervals maintained in the flat tree.
+func (flat *FlatIntervalTree) Len() int {
+	return flat.intervalsCount
+}
+
+// Query method returns all intervals in the flat tree which overlap given point, i.e. all intervals
+// for which (start <= x < end). The order of the intervals may differ from Query of the source tree.
+func (flat *FlatIntervalTree) Query(x int) []Interval {
+	var result []Interval
+	for node := 0; node >= 0; {
+		for _, interval := range flat.linear[flat.linearOffsets[node]:flat.linearOffsets[node+1]] {
+			if flat.config.contains(interval, x) {
+				result = append(result, interval)
+			}
+		}
+		low, high := flat.midOffsets[node], flat.midOffsets[node+1]
+		if x < flat.centers[node] {
+			for _, interval := range flat.byStart[low:high] {
+				if flat.config.lower(interval.Start) > x {
+					break
+				}
+				result = append(result, interval)
+			}
+			node = flat.lefts[node]
+		} else {
+			for _, interval := range flat.byEnd[low:high] {
+				if flat.config.upper(interval.End) <= x {
+					break
+				}
+				result = append(result, interval)
+			}
+			node = flat.rights[node]
+		}
+	}
+	return result
+}
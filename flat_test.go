@@ -0,0 +1,59 @@
+package gointervaltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatten(t *testing.T) {
+	assert := assert.New(t)
+	flat := NewIntervalTree(0, 100).Flatten()
+	assert.Equal(0, flat.Len())
+	assert.Empty(flat.Query(50))
+	tree := NewIntervalTree(0, 1000)
+	for i := 0; i < 300; i++ {
+		tree.AddInterval((i*37)%900, (i*37)%900+1+(i*13)%80, i)
+	}
+	tree.SoftRemove(37, 51, 1)
+	flat = tree.Flatten()
+	assert.Equal(tree.Len(), flat.Len())
+	tree.AddInterval(500, 600, "after")
+	tree.Sort()
+	for q := 0; q < 1000; q++ {
+		expected := tree.queryIntervals(q)
+		if q >= 500 && q < 600 {
+			expected = expected[:0]
+			for _, interval := range tree.queryIntervals(q) {
+				if interval.Data != "after" {
+					expected = append(expected, interval)
+				}
+			}
+		}
+		assert.ElementsMatch(expected, flat.Query(q))
+	}
+	capped := NewIntervalTree(0, 1<<20, WithMaxDepth(3), WithIntervalMode(Closed))
+	for i := 0; i < 100; i++ {
+		capped.AddInterval(i*10, i*10+15, i)
+	}
+	flat = capped.Flatten()
+	for q := 0; q < 1100; q++ {
+		assert.ElementsMatch(capped.queryIntervals(q), flat.Query(q))
+	}
+}
+
+func BenchmarkPointerQuery(b *testing.B) {
+	tree := buildLargeTree()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		tree.query((n * 7919) % 1000000)
+	}
+}
+
+func BenchmarkFlatQuery(b *testing.B) {
+	flat := buildLargeTree().Flatten()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		flat.Query((n * 7919) % 1000000)
+	}
+}
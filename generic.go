@@ -0,0 +1,136 @@
+package gointervaltree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// GenericIntervalTree struct defines a type-safe IntervalTree whose intervals carry data of type T,
+// so that callers never need type assertions on results.
+type GenericIntervalTree[T any] struct {
+	tree *IntervalTree
+}
+
+// GenericInterval struct defines a single [Start, End) interval together with its typed data.
+type GenericInterval[T any] struct {
+	Start int
+	End   int
+	Data  T
+}
+
+// NewGenericIntervalTree method instantiates an instance of GenericIntervalTree struct over [min, max),
+// the options are the same as for NewIntervalTree.
+func NewGenericIntervalTree[T any](min int, max int, options ...Option) *GenericIntervalTree[T] {
+	return &GenericIntervalTree[T]{tree: NewIntervalTree(min, max, options...)}
+}
+
+// toGeneric function converts untyped intervals into typed ones. Nil data stored for an interface type T
+// holds no dynamic type to assert, it is converted into the zero T, i.e. nil as well.
+func toGeneric[T any](intervals []Interval) []GenericInterval[T] {
+	var result []GenericInterval[T]
+	for _, interval := range intervals {
+		data, _ := interval.Data.(T)
+		result = append(result, GenericInterval[T]{Start: interval.Start, End: interval.End, Data: data})
+	}
+	return result
+}
+
+// AddInterval method adds intervals to the tree without sorting them along the way, see IntervalTree.AddInterval.
+func (tree *GenericIntervalTree[T]) AddInterval(start int, end int, data T) error {
+	return tree.tree.AddInterval(start, end, data)
+}
+
+// Sort method is used to sort intervals within the tree and must be invoked after adding intervals.
+func (tree *GenericIntervalTree[T]) Sort() {
+	tree.tree.Sort()
+}
+
+// Query method returns all intervals in the tree which overlap given point, i.e. all intervals,
+// for which (start <= x < end).
+func (tree *GenericIntervalTree[T]) Query(x int) []GenericInterval[T] {
+	return toGeneric[T](tree.tree.queryIntervals(x))
+}
+
+// QueryRange method returns all intervals in the tree which overlap given range [low, high),
+// i.e. all intervals for which (start < high && low < end).
+func (tree *GenericIntervalTree[T]) QueryRange(low int, high int) []GenericInterval[T] {
+	return toGeneric[T](tree.tree.QueryRange(low, high))
+}
+
+// Len method represents the number of intervals maintained in the tree.
+func (tree *GenericIntervalTree[T]) Len() int {
+	return tree.tree.Len()
+}
+
+// IterTyped method returns a slice of all intervals maintained in the tree.
+func (tree *GenericIntervalTree[T]) IterTyped() []GenericInterval[T] {
+	return toGeneric[T](tree.tree.intervals())
+}
+
+// Save method writes the bounds and all intervals of the tree to w, the data of each interval being serialized
+// by encode. All numbers are written as little-endian int64 values, each encoded payload is prefixed by its length.
+func (tree *GenericIntervalTree[T]) Save(w io.Writer, encode func(T) ([]byte, error)) error {
+	intervals := tree.IterTyped()
+	header := []int64{int64(tree.tree.min), int64(tree.tree.max), int64(len(intervals))}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, interval := range intervals {
+		payload, err := encode(interval.Data)
+		if err != nil {
+			return fmt.Errorf("encoding data of [%d, %d): %w", interval.Start, interval.End, err)
+		}
+		fields := []int64{int64(interval.Start), int64(interval.End), int64(len(payload))}
+		if err := binary.Write(w, binary.LittleEndian, fields); err != nil {
+			return err
+		}
+		if _, err := w.Write(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load function reads a tree written by Save from r decoding the data of each interval by decode, the options
+// are the same as for NewIntervalTree. The returned tree is sorted, no tree is returned along with an error for
+// truncated input, an invalid interval or a payload exceeding the remaining input.
+func Load[T any](r io.Reader, decode func([]byte) (T, error), options ...Option) (*GenericIntervalTree[T], error) {
+	header := make([]int64, 3)
+	if err := binary.Read(r, binary.LittleEndian, header); err != nil {
+		return nil, err
+	}
+	if header[0] >= header[1] || header[2] < 0 {
+		return nil, fmt.Errorf("invalid tree header: bounds [%d, %d), %d intervals", header[0], header[1], header[2])
+	}
+	tree := NewGenericIntervalTree[T](int(header[0]), int(header[1]), options...)
+	fields := make([]int64, 3)
+	for i := int64(0); i < header[2]; i++ {
+		if err := binary.Read(r, binary.LittleEndian, fields); err != nil {
+			return nil, err
+		}
+		if fields[2] < 0 {
+			return nil, fmt.Errorf("invalid payload length %d of [%d, %d)", fields[2], fields[0], fields[1])
+		}
+		if err := tree.tree.validate(int(fields[0]), int(fields[1])); err != nil {
+			return nil, fmt.Errorf("invalid interval %d: %w", i, err)
+		}
+		// the payload buffer grows with the bytes actually read, so that a corrupt length fails on the
+		// truncated stream instead of allocating it upfront
+		var payload bytes.Buffer
+		if _, err := io.CopyN(&payload, r, fields[2]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		data, err := decode(payload.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("decoding data of [%d, %d): %w", fields[0], fields[1], err)
+		}
+		tree.AddInterval(int(fields[0]), int(fields[1]), data)
+	}
+	tree.Sort()
+	return tree, nil
+}
@@ -0,0 +1,115 @@
+package gointervaltree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type feature struct {
+	Name  string
+	Score int
+}
+
+func TestGenericIntervalTree(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewGenericIntervalTree[feature](0, 100)
+	tree.AddInterval(10, 30, feature{"a", 1})
+	tree.AddInterval(20, 60, feature{"b", 2})
+	tree.AddInterval(70, 80, feature{"c", 3})
+	tree.Sort()
+	result := tree.Query(25)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Data.Name < result[j].Data.Name
+	})
+	assert.Equal([]GenericInterval[feature]{{10, 30, feature{"a", 1}}, {20, 60, feature{"b", 2}}}, result)
+	total := 0
+	for _, interval := range tree.QueryRange(50, 75) {
+		total += interval.Data.Score
+	}
+	assert.Equal(5, total)
+	assert.Equal(3, tree.Len())
+	names := []string{}
+	for _, interval := range tree.IterTyped() {
+		names = append(names, interval.Data.Name)
+	}
+	assert.ElementsMatch([]string{"a", "b", "c"}, names)
+	assert.Empty(tree.Query(95))
+}
+
+func TestSaveLoad(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewGenericIntervalTree[feature](-50, 100)
+	tree.AddInterval(-10, 20, feature{"a", 1})
+	tree.AddInterval(40, 60, feature{"b", 2})
+	tree.AddInterval(40, 60, feature{"", 0})
+	tree.Sort()
+	var buffer bytes.Buffer
+	encode := func(f feature) ([]byte, error) { return json.Marshal(f) }
+	decode := func(data []byte) (feature, error) {
+		var f feature
+		err := json.Unmarshal(data, &f)
+		return f, err
+	}
+	assert.NoError(tree.Save(&buffer, encode))
+	loaded, err := Load[feature](bytes.NewReader(buffer.Bytes()), decode)
+	assert.NoError(err)
+	assert.Equal(-50, loaded.tree.min)
+	assert.Equal(100, loaded.tree.max)
+	assert.ElementsMatch(tree.IterTyped(), loaded.IterTyped())
+	assert.ElementsMatch(tree.Query(50), loaded.Query(50))
+	_, err = Load[feature](bytes.NewReader(buffer.Bytes()[:buffer.Len()-3]), decode)
+	assert.Error(err)
+	failing := errors.New("failing codec")
+	_, err = Load[feature](bytes.NewReader(buffer.Bytes()), func([]byte) (feature, error) {
+		return feature{}, failing
+	})
+	assert.ErrorIs(err, failing)
+	assert.ErrorIs(tree.Save(&buffer, func(feature) ([]byte, error) { return nil, failing }), failing)
+	var hostile bytes.Buffer
+	binary.Write(&hostile, binary.LittleEndian, []int64{0, 100, 1, 10, 20, 1 << 62})
+	hostile.WriteString("short")
+	_, err = Load[feature](&hostile, decode)
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+	var outside bytes.Buffer
+	binary.Write(&outside, binary.LittleEndian, []int64{0, 100, 1, 90, 120, 0})
+	_, err = Load[feature](&outside, decode)
+	assert.ErrorIs(err, ErrOutOfBounds)
+	var truncated bytes.Buffer
+	binary.Write(&truncated, binary.LittleEndian, []int64{0, 100, 1 << 40, 10, 20, 0})
+	_, err = Load[feature](&truncated, func([]byte) (feature, error) { return feature{}, nil })
+	assert.Error(err)
+}
+
+func TestGenericIntervalTreeNilInterface(t *testing.T) {
+	assert := assert.New(t)
+	failing := errors.New("failing")
+	tree := NewGenericIntervalTree[error](0, 10)
+	tree.AddInterval(0, 5, nil)
+	tree.AddInterval(3, 8, failing)
+	tree.Sort()
+	assert.ElementsMatch([]GenericInterval[error]{{0, 5, nil}, {3, 8, failing}}, tree.Query(4))
+	assert.Equal([]GenericInterval[error]{{0, 5, nil}}, tree.QueryRange(0, 2))
+	assert.ElementsMatch([]GenericInterval[error]{{0, 5, nil}, {3, 8, failing}}, tree.IterTyped())
+	var buffer bytes.Buffer
+	assert.NoError(tree.Save(&buffer, func(err error) ([]byte, error) {
+		if err == nil {
+			return nil, nil
+		}
+		return []byte(err.Error()), nil
+	}))
+	loaded, err := Load[error](&buffer, func(data []byte) (error, error) {
+		if len(data) == 0 {
+			return nil, nil
+		}
+		return errors.New(string(data)), nil
+	})
+	assert.NoError(err)
+	assert.Equal([]GenericInterval[error]{{0, 5, nil}}, loaded.Query(1))
+}
@@ -3,6 +3,10 @@
 package gointervaltree
 
 import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
 	"log"
 	"reflect"
 	"sort"
@@ -10,83 +14,583 @@ import (
 
 // IntervalTree struct defines data structure for indexing a set of integer intervals, e.g. [start, end).
 type IntervalTree struct {
-	min              int
-	max              int
-	center           int
-	singleInterval   []interface{}
-	leftSubtree      *IntervalTree
-	rightSubtree     *IntervalTree
-	midSortedByStart []interface{}
-	midSortedByEnd   []interface{}
+	min               int
+	max               int
+	center            int
+	singleInterval    *record
+	split             bool
+	leftSubtree       *IntervalTree
+	rightSubtree      *IntervalTree
+	midSortedByStart  []*record
+	midSortedByEnd    []*record
+	overflow          []*record
+	depth             int
+	tombstones        int
+	size              int
+	dirty             bool
+	unsorted          bool
+	observer          Observer
+	recentInserts     []*record
+	config            *treeConfig
+	insertsSinceCheck int
+	lenAtLastCheck    int
+	rebalances        int
+	insertions        int
+	index             *arrayIndex
+	registry          *dataRegistry
+}
+
+// treeConfig struct defines the options set at construction, shared by a tree and all its subtrees.
+type treeConfig struct {
+	eagerSort          bool
+	startShift         int
+	endShift           int
+	rebalanceThreshold float64
+	centerStrategy     CenterStrategy
+	maxDepth           int
+	insertionOrder     bool
+	rejectDegenerate   bool
+	sortedArrayIndex   bool
+	dataInterning      bool
+}
+
+// IntervalMode type defines which endpoints belong to the intervals maintained in a tree. Internally every
+// interval is placed as its half-open equivalent [lower, upper) of covered integer points, while the stored and
+// returned coordinates are kept as given.
+type IntervalMode int
+
+const (
+	// ClosedOpen mode is the default [start, end) mode, start is covered while end is not, so that adjacent
+	// intervals sharing an endpoint never overlap and start == end is an empty interval.
+	ClosedOpen IntervalMode = iota
+	// OpenClosed mode defines (start, end] intervals, end is covered and start is not, start == end is empty.
+	OpenClosed
+	// Closed mode defines [start, end] intervals covering both endpoints, thus start == end is a single point
+	// and adjacent intervals sharing an endpoint overlap at it.
+	Closed
+	// Open mode defines (start, end) intervals covering neither endpoint, thus at least end - start == 2
+	// is required for an interval to cover any point.
+	Open
+)
+
+// WithIntervalMode function returns an Option selecting the IntervalMode consulted by point and range queries
+// and by the size check on insert. Other methods operate on the stored coordinates as [start, end).
+func WithIntervalMode(mode IntervalMode) Option {
+	return func(config *treeConfig) {
+		config.startShift, config.endShift = 0, 0
+		if mode == OpenClosed || mode == Open {
+			config.startShift = 1
+		}
+		if mode == OpenClosed || mode == Closed {
+			config.endShift = 1
+		}
+	}
+}
+
+// lower method returns the first point covered by an interval with the given start.
+func (config *treeConfig) lower(start int) int {
+	return start + config.startShift
+}
+
+// upper method returns the point right after the last one covered by an interval with the given end.
+func (config *treeConfig) upper(end int) int {
+	return end + config.endShift
+}
+
+// valid method reports whether an interval with the given coordinates covers at least one point.
+func (config *treeConfig) valid(start int, end int) bool {
+	return config.upper(end)-config.lower(start) > 0
+}
+
+// overlaps method reports whether an interval covers any point of range [low, high).
+func (config *treeConfig) overlaps(interval Interval, low int, high int) bool {
+	return config.lower(interval.Start) < high && low < config.upper(interval.End)
+}
+
+// contains method reports whether an interval covers point x.
+func (config *treeConfig) contains(interval Interval, x int) bool {
+	return config.lower(interval.Start) <= x && x < config.upper(interval.End)
+}
+
+// Option type defines a functional option configuring an IntervalTree at construction.
+type Option func(config *treeConfig)
+
+// WithEagerSort function returns an Option making AddInterval keep the affected node sorted by inserting
+// intervals in order, so that queries never need a separate Sort at the cost of slower inserts.
+func WithEagerSort() Option {
+	return func(config *treeConfig) {
+		config.eagerSort = true
+	}
+}
+
+// WithInsertionOrder function returns an Option making the tree number the intervals in the order they are
+// inserted, so that IterInsertionOrder can return them in that order.
+func WithInsertionOrder() Option {
+	return func(config *treeConfig) {
+		config.insertionOrder = true
+	}
+}
+
+// WithRejectDegenerate function returns an Option making AddInterval and AddMulti return an error wrapping
+// ErrDegenerateInterval for intervals covering no points instead of silently skipping them.
+func WithRejectDegenerate() Option {
+	return func(config *treeConfig) {
+		config.rejectDegenerate = true
+	}
+}
+
+// maxUndo constant defines how many recent inserts are remembered for Undo.
+const maxUndo = 32
+
+// Observer interface defines callbacks which are invoked after the corresponding tree operations, e.g. for
+// collecting metrics. Only the tree the observer is set on reports operations, subtrees never do.
+type Observer interface {
+	// OnInsert is invoked after an interval [start, end) has been added to the tree.
+	OnInsert(start int, end int)
+	// OnQuery is invoked after querying point x has matched the given number of intervals.
+	OnQuery(x int, matched int)
+}
+
+// Interval struct defines a single [Start, End) interval together with its associated data.
+type Interval struct {
+	Start int
+	End   int
+	Data  interface{}
+}
+
+// record struct defines an interval stored in the tree along with its bookkeeping.
+type record struct {
+	Interval
+	tombstone bool
+	multi     bool
+	seq       int
+}
+
+// toSlice method represents the record as a (start, end, data) slice as returned by Query and Iter.
+func (r *record) toSlice() []interface{} {
+	return []interface{}{r.Start, r.End, r.Data}
 }
 
 // NewIntervalTree method instantiates an instance of IntervalTree struct creating a node for keeping intervals.
-func NewIntervalTree(min int, max int) (tree *IntervalTree) {
+func NewIntervalTree(min int, max int, options ...Option) (tree *IntervalTree) {
+	config := new(treeConfig)
+	for _, option := range options {
+		option(config)
+	}
+	return newNode(min, max, config)
+}
+
+// newNode function is a technical function creating a tree node sharing the given configuration.
+func newNode(min int, max int, config *treeConfig) (tree *IntervalTree) {
 	tree = new(IntervalTree)
 	tree.min = min
 	tree.max = max
 	if !(tree.min < tree.max) {
 		log.Panic("AssertionError: interval tree start must be numerically less than its end")
 	}
-	tree.center = (min + max) / 2
+	// floor of the midpoint even for negative bounds, (min + max) / 2 would round towards zero instead
+	tree.center = min + (max-min)/2
 	tree.singleInterval = nil
+	tree.split = false
 	tree.leftSubtree = nil
 	tree.rightSubtree = nil
-	tree.midSortedByStart = []interface{}{}
-	tree.midSortedByEnd = []interface{}{}
+	tree.midSortedByStart = []*record{}
+	tree.midSortedByEnd = []*record{}
+	tree.depth = 1
+	tree.config = config
 	return tree
 }
 
-// AddInterval method adds intervals to the tree without sorting them along the way.
-func (tree *IntervalTree) AddInterval(start int, end int, data interface{}) {
-	if (end - start) <= 0 {
+// newSubtree method is a technical method creating a child node over [min, max) one level below this node.
+func (tree *IntervalTree) newSubtree(min int, max int) *IntervalTree {
+	subtree := newNode(min, max, tree.config)
+	subtree.depth = tree.depth + 1
+	return subtree
+}
+
+// capped method reports whether the node is at the depth set by WithMaxDepth and thus never gets subtrees.
+func (tree *IntervalTree) capped() bool {
+	return tree.config.maxDepth > 0 && tree.depth >= tree.config.maxDepth
+}
+
+// overflows method reports whether an interval belongs to the overflow list of a split node, i.e. the node is
+// capped and the interval does not straddle its center.
+func (tree *IntervalTree) overflows(start int, end int) bool {
+	return tree.split && tree.capped() && (tree.config.upper(end) <= tree.center || tree.config.lower(start) > tree.center)
+}
+
+// SetObserver method installs an Observer on the tree, a nil observer disables reporting.
+func (tree *IntervalTree) SetObserver(observer Observer) {
+	tree.observer = observer
+}
+
+// AddInterval method adds intervals to the tree without sorting them along the way. Intervals covering no points
+// are skipped, an error is returned for them only if WithRejectDegenerate is set.
+func (tree *IntervalTree) AddInterval(start int, end int, data interface{}) error {
+	if !tree.config.valid(start, end) {
+		if tree.config.rejectDegenerate {
+			return degenerateError(start, end)
+		}
+		return nil
+	}
+	r := tree.newRecord(Interval{Start: start, End: end, Data: data})
+	depth := tree.addInterval(r)
+	tree.rememberInsert(r)
+	tree.maybeRebalance(depth)
+	if tree.observer != nil {
+		tree.observer.OnInsert(start, end)
+	}
+	return nil
+}
+
+// AddIntervals method adds all given intervals to the tree and sorts it once afterwards, which is equivalent
+// to calling AddInterval for each of them followed by Sort. If a CenterStrategy is set, the tree is rebuilt
+// using it instead.
+func (tree *IntervalTree) AddIntervals(intervals []Interval) {
+	for _, interval := range intervals {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	if tree.config.centerStrategy != nil {
+		tree.Rebalance()
 		return
 	}
-	if tree.singleInterval == nil {
-		tree.singleInterval = []interface{}{start, end, data}
-	} else if reflect.DeepEqual(tree.singleInterval, []interface{}{0}) {
-		tree.addIntervalMain(start, end, data)
+	tree.Sort()
+}
+
+// newRecord method is a technical method returning the record of an interval about to be inserted, with its
+// data interned when WithDataInterning is set and numbered when WithInsertionOrder is set.
+func (tree *IntervalTree) newRecord(interval Interval) *record {
+	if tree.config.dataInterning {
+		if tree.registry == nil {
+			tree.registry = new(dataRegistry)
+		}
+		interval.Data = tree.registry.intern(interval.Data)
+	}
+	r := &record{Interval: interval}
+	tree.numberInsert(r)
+	return r
+}
+
+// numberInsert method is a technical method assigning the next insertion sequence number to a record
+// when WithInsertionOrder is set.
+func (tree *IntervalTree) numberInsert(r *record) {
+	if tree.config.insertionOrder {
+		tree.insertions++
+		r.seq = tree.insertions
+	}
+}
+
+// MergeIntervals method validates the given intervals and adds all of them to the tree sorting it once
+// afterwards. The merge is atomic: if any interval is degenerate or out of the tree bounds, an error wrapping
+// ErrDegenerateInterval or ErrOutOfBounds is returned for the first such interval and nothing is added.
+func (tree *IntervalTree) MergeIntervals(intervals []Interval) error {
+	for _, interval := range intervals {
+		if err := tree.validate(interval.Start, interval.End); err != nil {
+			return err
+		}
+	}
+	for _, interval := range intervals {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	return nil
+}
+
+// AddIntervalsCollectErrors method adds every valid one of the given intervals to the tree and sorts it once
+// afterwards. Instead of stopping at the first invalid interval, an error is collected for each degenerate or out
+// of bounds one, wrapping ErrDegenerateInterval or ErrOutOfBounds and naming its position. No errors are returned
+// if all intervals were added.
+func (tree *IntervalTree) AddIntervalsCollectErrors(intervals []Interval) []error {
+	var errs []error
+	for i, interval := range intervals {
+		if err := tree.validate(interval.Start, interval.End); err != nil {
+			errs = append(errs, fmt.Errorf("interval %d: %w", i, err))
+			continue
+		}
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	return errs
+}
+
+// FromMap function returns a new sorted tree over [min, max) holding an interval [key[0], key[1]) for every
+// entry of m with the entry value as data. The keys are added in ascending order, and an error wrapping
+// ErrDegenerateInterval or ErrOutOfBounds is returned for the first invalid one.
+func FromMap(min int, max int, m map[[2]int]interface{}) (*IntervalTree, error) {
+	intervals := make([]Interval, 0, len(m))
+	for key, value := range m {
+		intervals = append(intervals, Interval{Start: key[0], End: key[1], Data: value})
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start < intervals[j].Start ||
+			(intervals[i].Start == intervals[j].Start && intervals[i].End < intervals[j].End)
+	})
+	tree := NewIntervalTree(min, max)
+	if err := tree.MergeIntervals(intervals); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// validate method is a technical method returning an error if an interval is degenerate or does not lie
+// within [min, max) of the tree.
+func (tree *IntervalTree) validate(start int, end int) error {
+	if !tree.config.valid(start, end) {
+		return degenerateError(start, end)
+	}
+	if tree.config.lower(start) < tree.min || tree.config.upper(end) > tree.max {
+		return fmt.Errorf("%w: [%d, %d) is not within [%d, %d)", ErrOutOfBounds, start, end, tree.min, tree.max)
+	}
+	return nil
+}
+
+// degenerateError function returns an error wrapping ErrDegenerateInterval for the interval [start, end).
+func degenerateError(start int, end int) error {
+	return fmt.Errorf("%w: [%d, %d)", ErrDegenerateInterval, start, end)
+}
+
+// rememberInsert method is a technical method keeping track of the most recent inserts for Undo.
+func (tree *IntervalTree) rememberInsert(r *record) {
+	if len(tree.recentInserts) == maxUndo {
+		copy(tree.recentInserts, tree.recentInserts[1:])
+		tree.recentInserts = tree.recentInserts[:maxUndo-1]
+	}
+	tree.recentInserts = append(tree.recentInserts, r)
+}
+
+// Undo method removes the most recently inserted interval which is still maintained in the tree keeping
+// the sort order intact, intervals removed by SoftRemove are skipped. Up to 32 recent inserts are remembered,
+// false is returned if there are none left.
+func (tree *IntervalTree) Undo() bool {
+	for len(tree.recentInserts) > 0 {
+		r := tree.recentInserts[len(tree.recentInserts)-1]
+		tree.recentInserts[len(tree.recentInserts)-1] = nil
+		tree.recentInserts = tree.recentInserts[:len(tree.recentInserts)-1]
+		if r.tombstone {
+			continue
+		}
+		if tree.removeRecord(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeRecord method is a technical method physically removing the given record from the tree,
+// it returns false if the record is not maintained in the tree.
+func (tree *IntervalTree) removeRecord(r *record) bool {
+	removed := false
+	tree.index = nil
+	if !tree.split {
+		if tree.singleInterval == r {
+			tree.singleInterval = nil
+			removed = true
+		}
+	} else if tree.overflows(r.Start, r.End) {
+		if index := indexOfRecord(tree.overflow, r); index >= 0 {
+			tree.overflow = append(tree.overflow[:index], tree.overflow[index+1:]...)
+			removed = true
+		}
+	} else if tree.config.upper(r.End) <= tree.center {
+		removed = tree.leftSubtree != nil && tree.leftSubtree.removeRecord(r)
+	} else if tree.config.lower(r.Start) > tree.center {
+		removed = tree.rightSubtree != nil && tree.rightSubtree.removeRecord(r)
+	} else if index := indexOfRecord(tree.midSortedByStart, r); index >= 0 {
+		tree.midSortedByStart = append(tree.midSortedByStart[:index], tree.midSortedByStart[index+1:]...)
+		index = indexOfRecord(tree.midSortedByEnd, r)
+		tree.midSortedByEnd = append(tree.midSortedByEnd[:index], tree.midSortedByEnd[index+1:]...)
+		if r.tombstone {
+			tree.tombstones--
+		}
+		removed = true
+	}
+	if removed && !r.tombstone {
+		tree.size--
+	}
+	return removed
+}
+
+// RemoveByCoords method removes all intervals with exactly the given coordinates regardless of their data
+// and returns the number of intervals removed. The sort order of the remaining intervals is kept intact.
+func (tree *IntervalTree) RemoveByCoords(start int, end int) int {
+	removed := 0
+	tree.index = nil
+	if !tree.split {
+		if tree.singleInterval != nil && tree.singleInterval.Start == start && tree.singleInterval.End == end {
+			if !tree.singleInterval.tombstone {
+				removed = 1
+			}
+			tree.singleInterval = nil
+		}
+	} else if tree.overflows(start, end) {
+		kept := tree.overflow[:0]
+		for _, r := range tree.overflow {
+			if r.Start == start && r.End == end {
+				if !r.tombstone {
+					removed++
+				}
+				continue
+			}
+			kept = append(kept, r)
+		}
+		for i := len(kept); i < len(tree.overflow); i++ {
+			tree.overflow[i] = nil
+		}
+		tree.overflow = kept
+	} else if tree.config.upper(end) <= tree.center {
+		if tree.leftSubtree != nil {
+			removed = tree.leftSubtree.RemoveByCoords(start, end)
+		}
+	} else if tree.config.lower(start) > tree.center {
+		if tree.rightSubtree != nil {
+			removed = tree.rightSubtree.RemoveByCoords(start, end)
+		}
 	} else {
-		tree.addIntervalMain(tree.singleInterval[0].(int), tree.singleInterval[1].(int), tree.singleInterval[2])
-		tree.singleInterval = []interface{}{0}
-		tree.addIntervalMain(start, end, data)
+		kept := tree.midSortedByStart[:0]
+		for _, r := range tree.midSortedByStart {
+			if r.Start == start && r.End == end {
+				if r.tombstone {
+					tree.tombstones--
+				} else {
+					removed++
+				}
+				continue
+			}
+			kept = append(kept, r)
+		}
+		for i := len(kept); i < len(tree.midSortedByStart); i++ {
+			tree.midSortedByStart[i] = nil
+		}
+		tree.midSortedByStart = kept
+		kept = tree.midSortedByEnd[:0]
+		for _, r := range tree.midSortedByEnd {
+			if r.Start != start || r.End != end {
+				kept = append(kept, r)
+			}
+		}
+		for i := len(kept); i < len(tree.midSortedByEnd); i++ {
+			tree.midSortedByEnd[i] = nil
+		}
+		tree.midSortedByEnd = kept
+	}
+	tree.size -= removed
+	return removed
+}
+
+// indexOfRecord function returns the position of the given record in a slice, -1 if it is absent.
+func indexOfRecord(records []*record, r *record) int {
+	for i, element := range records {
+		if element == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// addInterval method is a technical method used inside AddInterval for recursive insertion, it returns the depth
+// of the deepest node affected by the insertion relative to this node, which is 1 for this node itself.
+func (tree *IntervalTree) addInterval(r *record) int {
+	if !tree.config.valid(r.Start, r.End) {
+		return 0
+	}
+	if !r.tombstone {
+		tree.size++
+	}
+	tree.unsorted = true
+	tree.index = nil
+	if tree.singleInterval == nil && !tree.split {
+		tree.singleInterval = r
+		return 1
+	} else if tree.split {
+		return tree.addIntervalMain(r)
+	} else {
+		single := tree.singleInterval
+		tree.singleInterval = nil
+		tree.split = true
+		depth := tree.addIntervalMain(single)
+		if other := tree.addIntervalMain(r); other > depth {
+			depth = other
+		}
+		return depth
 	}
 }
 
 // addIntervalMain method is a technical method used inside AddInterval.
-func (tree *IntervalTree) addIntervalMain(start int, end int, data interface{}) {
-	if end <= tree.center {
+func (tree *IntervalTree) addIntervalMain(r *record) int {
+	if tree.overflows(r.Start, r.End) {
+		tree.overflow = append(tree.overflow, r)
+		return 1
+	} else if tree.config.upper(r.End) <= tree.center {
 		if tree.leftSubtree == nil {
-			tree.leftSubtree = NewIntervalTree(tree.min, tree.center)
+			tree.leftSubtree = tree.newSubtree(tree.min, tree.center)
 		}
-		tree.leftSubtree.AddInterval(start, end, data)
-	} else if start > tree.center {
+		return tree.leftSubtree.addInterval(r) + 1
+	} else if tree.config.lower(r.Start) > tree.center {
 		if tree.rightSubtree == nil {
-			tree.rightSubtree = NewIntervalTree(tree.center, tree.max)
+			tree.rightSubtree = tree.newSubtree(tree.center, tree.max)
+		}
+		return tree.rightSubtree.addInterval(r) + 1
+	} else if tree.config.eagerSort {
+		i := sort.Search(len(tree.midSortedByStart), func(i int) bool {
+			return tree.midSortedByStart[i].Start > r.Start
+		})
+		tree.midSortedByStart = insertRecord(tree.midSortedByStart, i, r)
+		i = sort.Search(len(tree.midSortedByEnd), func(i int) bool {
+			return tree.midSortedByEnd[i].End < r.End
+		})
+		tree.midSortedByEnd = insertRecord(tree.midSortedByEnd, i, r)
+		if r.tombstone {
+			tree.tombstones++
 		}
-		tree.rightSubtree.AddInterval(start, end, data)
+		return 1
 	} else {
-		tree.midSortedByStart = append(tree.midSortedByStart, []interface{}{start, end, data})
-		tree.midSortedByEnd = append(tree.midSortedByEnd, []interface{}{start, end, data})
+		tree.midSortedByStart = append(tree.midSortedByStart, r)
+		tree.midSortedByEnd = append(tree.midSortedByEnd, r)
+		tree.dirty = true
+		if r.tombstone {
+			tree.tombstones++
+		}
+		return 1
 	}
 }
 
+// insertRecord function inserts a record into a slice at the given position.
+func insertRecord(records []*record, i int, r *record) []*record {
+	records = append(records, nil)
+	copy(records[i+1:], records[i:])
+	records[i] = r
+	return records
+}
+
 // Sort method is used to sort intervals within the tree and must be invoked after adding intervals.
+// Only nodes which received new intervals since the last sort are re-sorted, clean subtrees are skipped.
 func (tree *IntervalTree) Sort() {
-	if tree.singleInterval == nil || !reflect.DeepEqual(tree.singleInterval, []interface{}{0}) {
+	tree.sortNodes()
+	if tree.config.sortedArrayIndex && tree.index == nil {
+		tree.index = newArrayIndex(tree)
+	}
+}
+
+// sortNodes method is a technical method used inside Sort for recursive sorting.
+func (tree *IntervalTree) sortNodes() {
+	if !tree.split || !tree.unsorted {
 		return
 	}
-	sort.Slice(tree.midSortedByStart, func(i, j int) bool {
-		return tree.midSortedByStart[i].([]interface{})[0].(int) < tree.midSortedByStart[j].([]interface{})[0].(int)
-	})
-	sort.Slice(tree.midSortedByEnd, func(i, j int) bool {
-		return tree.midSortedByEnd[i].([]interface{})[1].(int) > tree.midSortedByEnd[j].([]interface{})[1].(int)
-	})
+	if tree.dirty {
+		sort.Slice(tree.midSortedByStart, func(i, j int) bool {
+			return tree.midSortedByStart[i].Start < tree.midSortedByStart[j].Start
+		})
+		sort.Slice(tree.midSortedByEnd, func(i, j int) bool {
+			return tree.midSortedByEnd[i].End > tree.midSortedByEnd[j].End
+		})
+		tree.dirty = false
+	}
+	tree.unsorted = false
 	if tree.leftSubtree != nil {
-		tree.leftSubtree.Sort()
+		tree.leftSubtree.sortNodes()
 	}
 	if tree.rightSubtree != nil {
-		tree.rightSubtree.Sort()
+		tree.rightSubtree.sortNodes()
 	}
 }
 
@@ -94,20 +598,52 @@ func (tree *IntervalTree) Sort() {
 // i.e. all (start, end, data) records, for which (start <= x < end).
 func (tree *IntervalTree) Query(x int) []interface{} {
 	var result []interface{}
-	if tree.singleInterval == nil {
+	for _, r := range tree.query(x) {
+		result = append(result, r.toSlice())
+	}
+	if tree.observer != nil {
+		tree.observer.OnQuery(x, len(result))
+	}
+	return result
+}
+
+// query method is a technical method used inside Query for recursive lookup, it uses the sorted array built
+// by WithSortedArrayIndex when that is expected to be faster.
+func (tree *IntervalTree) query(x int) []*record {
+	if result, ok := tree.queryIndexed(x); ok {
 		return result
-	} else if !reflect.DeepEqual(tree.singleInterval, []interface{}{0}) {
-		if tree.singleInterval[0].(int) <= x && x < tree.singleInterval[1].(int) {
+	}
+	return tree.queryWhere(x, nil)
+}
+
+// queryWhere method is a technical method returning the live records overlapping given point which satisfy keep,
+// a nil keep accepting all of them. The filter is applied during the lookup.
+func (tree *IntervalTree) queryWhere(x int, keep func(r *record) bool) []*record {
+	var result []*record
+	kept := func(r *record) bool {
+		return !r.tombstone && (keep == nil || keep(r))
+	}
+	if !tree.split {
+		if tree.singleInterval != nil && tree.config.contains(tree.singleInterval.Interval, x) &&
+			kept(tree.singleInterval) {
 			result = append(result, tree.singleInterval)
 		}
 		return result
-	} else if x < tree.center {
+	}
+	for _, element := range tree.overflow {
+		if tree.config.contains(element.Interval, x) && kept(element) {
+			result = append(result, element)
+		}
+	}
+	if x < tree.center {
 		if tree.leftSubtree != nil {
-			result = append(result, tree.leftSubtree.Query(x)...)
+			result = append(result, tree.leftSubtree.queryWhere(x, keep)...)
 		}
 		for _, element := range tree.midSortedByStart {
-			if element.([]interface{})[0].(int) <= x {
-				result = append(result, element)
+			if tree.config.lower(element.Start) <= x {
+				if kept(element) {
+					result = append(result, element)
+				}
 			} else {
 				break
 			}
@@ -115,28 +651,151 @@ func (tree *IntervalTree) Query(x int) []interface{} {
 		return result
 	} else {
 		for _, element := range tree.midSortedByEnd {
-			if element.([]interface{})[1].(int) > x {
-				result = append(result, element)
+			if tree.config.upper(element.End) > x {
+				if kept(element) {
+					result = append(result, element)
+				}
 			} else {
 				break
 			}
 		}
 		if tree.rightSubtree != nil {
-			result = append(result, tree.rightSubtree.Query(x)...)
+			result = append(result, tree.rightSubtree.queryWhere(x, keep)...)
 		}
 		return result
 	}
 }
 
+// QueryRange method returns all intervals in the tree which overlap given range [low, high),
+// i.e. all intervals for which (start < high && low < end).
+func (tree *IntervalTree) QueryRange(low int, high int) []Interval {
+	var result []Interval
+	if (high - low) <= 0 {
+		return result
+	}
+	for _, r := range tree.queryRange(low, high) {
+		result = append(result, r.Interval)
+	}
+	return result
+}
+
+// queryRange method is a technical method used inside QueryRange for recursive lookup.
+func (tree *IntervalTree) queryRange(low int, high int) []*record {
+	var result []*record
+	if !tree.split {
+		if tree.singleInterval != nil && !tree.singleInterval.tombstone &&
+			tree.config.overlaps(tree.singleInterval.Interval, low, high) {
+			result = append(result, tree.singleInterval)
+		}
+		return result
+	}
+	for _, element := range tree.overflow {
+		if !element.tombstone && tree.config.overlaps(element.Interval, low, high) {
+			result = append(result, element)
+		}
+	}
+	if high <= tree.center {
+		if tree.leftSubtree != nil {
+			result = append(result, tree.leftSubtree.queryRange(low, high)...)
+		}
+		for _, element := range tree.midSortedByStart {
+			if tree.config.lower(element.Start) < high {
+				if !element.tombstone {
+					result = append(result, element)
+				}
+			} else {
+				break
+			}
+		}
+		return result
+	} else if low > tree.center {
+		for _, element := range tree.midSortedByEnd {
+			if tree.config.upper(element.End) > low {
+				if !element.tombstone {
+					result = append(result, element)
+				}
+			} else {
+				break
+			}
+		}
+		if tree.rightSubtree != nil {
+			result = append(result, tree.rightSubtree.queryRange(low, high)...)
+		}
+		return result
+	} else {
+		if tree.leftSubtree != nil {
+			result = append(result, tree.leftSubtree.queryRange(low, high)...)
+		}
+		for _, element := range tree.midSortedByStart {
+			if !element.tombstone {
+				result = append(result, element)
+			}
+		}
+		if tree.rightSubtree != nil {
+			result = append(result, tree.rightSubtree.queryRange(low, high)...)
+		}
+		return result
+	}
+}
+
+// IntervalOverlap struct defines an interval matched by a range query along with the length of its part
+// falling inside the queried range.
+type IntervalOverlap struct {
+	Interval
+	OverlapLen int
+}
+
+// QueryRangeWithOverlap method returns all intervals in the tree which overlap given range [low, high) along with
+// the overlap length min(end, high) - max(start, low) of each.
+func (tree *IntervalTree) QueryRangeWithOverlap(low int, high int) []IntervalOverlap {
+	var result []IntervalOverlap
+	for _, interval := range tree.QueryRange(low, high) {
+		result = append(result, IntervalOverlap{Interval: interval, OverlapLen: overlapLength(interval, low, high)})
+	}
+	return result
+}
+
+// TotalOverlapLength method returns the sum of the overlap lengths min(end, high) - max(start, low) of all
+// intervals overlapping given range [low, high), ranges covered by several intervals are counted for each of them
+// unlike in CoverageLength.
+func (tree *IntervalTree) TotalOverlapLength(low int, high int) int {
+	total := 0
+	for _, interval := range tree.QueryRange(low, high) {
+		total += overlapLength(interval, low, high)
+	}
+	return total
+}
+
+// overlapLength function returns the length of the part of an interval falling inside range [low, high).
+func overlapLength(interval Interval, low int, high int) int {
+	start, end := interval.Start, interval.End
+	if start < low {
+		start = low
+	}
+	if end > high {
+		end = high
+	}
+	if end < start {
+		return 0
+	}
+	return end - start
+}
+
 // Len method represents the number of intervals maintained in the tree, zero- or negative-size intervals
 // are not registered.
 func (tree *IntervalTree) Len() int {
-	if tree.singleInterval == nil {
-		return 0
-	} else if !reflect.DeepEqual(tree.singleInterval, []interface{}{0}) {
+	if !tree.split {
+		if tree.singleInterval == nil || tree.singleInterval.tombstone {
+			return 0
+		}
 		return 1
 	} else {
-		size := len(tree.midSortedByStart)
+		size := len(tree.midSortedByStart) - tree.tombstones
+		for _, element := range tree.overflow {
+			if !element.tombstone {
+				size++
+			}
+		}
 		if tree.leftSubtree != nil {
 			size += tree.leftSubtree.Len()
 		}
@@ -150,21 +809,471 @@ func (tree *IntervalTree) Len() int {
 // Iter method returns a slice of all intervals maintained in the tree.
 func (tree *IntervalTree) Iter() []interface{} {
 	var result []interface{}
-	if tree.singleInterval == nil {
-		return result
-	} else if !reflect.DeepEqual(tree.singleInterval, []interface{}{0}) {
-		result = append(result, tree.singleInterval)
+	for _, r := range tree.records() {
+		result = append(result, r.toSlice())
+	}
+	return result
+}
+
+// IterSorted method returns a slice of all intervals maintained in the tree sorted by start, then by end.
+func (tree *IntervalTree) IterSorted() []Interval {
+	result := tree.intervals()
+	sortIntervals(result)
+	return result
+}
+
+// IterInsertionOrder method returns a slice of all intervals maintained in the tree in the order they were
+// inserted. Without WithInsertionOrder no order is recorded and the intervals are returned in Iter order.
+func (tree *IntervalTree) IterInsertionOrder() []Interval {
+	records := tree.records()
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].seq < records[j].seq
+	})
+	result := make([]Interval, len(records))
+	for i, r := range records {
+		result[i] = r.Interval
+	}
+	return result
+}
+
+// IterBatched method passes all intervals maintained in the tree, in Iter order, to fn in batches of batchSize
+// intervals, the last batch holding the remainder. The tree is traversed while the batches are filled, so that
+// at most one batch is held at a time, and the batch slice is reused by the following call of fn. The traversal
+// stops at the first error returned by fn, which is returned.
+func (tree *IntervalTree) IterBatched(batchSize int, fn func(batch []Interval) error) error {
+	if batchSize <= 0 {
+		log.Panic("AssertionError: batch size must be positive")
+	}
+	batch := make([]Interval, 0, batchSize)
+	var err error
+	tree.walkRecords(func(r *record) bool {
+		batch = append(batch, r.Interval)
+		if len(batch) == batchSize {
+			err = fn(batch)
+			batch = batch[:0]
+		}
+		return err == nil
+	})
+	if err == nil && len(batch) > 0 {
+		err = fn(batch)
+	}
+	return err
+}
+
+// walkRecords method is a technical method calling fn for every live record maintained in the tree in Iter
+// order until fn returns false, it reports whether the walk was completed.
+func (tree *IntervalTree) walkRecords(fn func(r *record) bool) bool {
+	if !tree.split {
+		return tree.singleInterval == nil || tree.singleInterval.tombstone || fn(tree.singleInterval)
+	}
+	if tree.leftSubtree != nil && !tree.leftSubtree.walkRecords(fn) {
+		return false
+	}
+	if tree.rightSubtree != nil && !tree.rightSubtree.walkRecords(fn) {
+		return false
+	}
+	for _, elements := range [][]*record{tree.overflow, tree.midSortedByStart} {
+		for _, element := range elements {
+			if !element.tombstone && !fn(element) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sortIntervals function sorts intervals by start, then by end, keeping the order of equal intervals.
+func sortIntervals(intervals []Interval) {
+	sort.SliceStable(intervals, func(i, j int) bool {
+		if intervals[i].Start != intervals[j].Start {
+			return intervals[i].Start < intervals[j].Start
+		}
+		return intervals[i].End < intervals[j].End
+	})
+}
+
+// records method is a technical method returning all live records maintained in the tree in Iter order.
+func (tree *IntervalTree) records() []*record {
+	var result []*record
+	if !tree.split {
+		if tree.singleInterval != nil && !tree.singleInterval.tombstone {
+			result = append(result, tree.singleInterval)
+		}
 		return result
 	} else {
 		if tree.leftSubtree != nil {
-			result = append(result, tree.leftSubtree.Iter()...)
+			result = append(result, tree.leftSubtree.records()...)
 		}
 		if tree.rightSubtree != nil {
-			result = append(result, tree.rightSubtree.Iter()...)
+			result = append(result, tree.rightSubtree.records()...)
+		}
+		for _, element := range tree.overflow {
+			if !element.tombstone {
+				result = append(result, element)
+			}
 		}
 		for _, element := range tree.midSortedByStart {
-			result = append(result, element)
+			if !element.tombstone {
+				result = append(result, element)
+			}
 		}
 		return result
 	}
 }
+
+// intervals method is a technical method returning all intervals maintained in the tree in Iter order.
+func (tree *IntervalTree) intervals() []Interval {
+	var result []Interval
+	for _, r := range tree.records() {
+		result = append(result, r.Interval)
+	}
+	return result
+}
+
+// findRecord method is a technical method returning the first live record with given coordinates satisfying
+// matches, nil if there is none. Only the node the coordinates would be placed into is searched.
+func (tree *IntervalTree) findRecord(start int, end int, matches func(r *record) bool) *record {
+	match := func(r *record) bool {
+		return !r.tombstone && r.Start == start && r.End == end && matches(r)
+	}
+	if !tree.split {
+		if tree.singleInterval != nil && match(tree.singleInterval) {
+			return tree.singleInterval
+		}
+		return nil
+	} else if tree.overflows(start, end) {
+		for _, element := range tree.overflow {
+			if match(element) {
+				return element
+			}
+		}
+		return nil
+	} else if tree.config.upper(end) <= tree.center {
+		if tree.leftSubtree != nil {
+			return tree.leftSubtree.findRecord(start, end, matches)
+		}
+		return nil
+	} else if tree.config.lower(start) > tree.center {
+		if tree.rightSubtree != nil {
+			return tree.rightSubtree.findRecord(start, end, matches)
+		}
+		return nil
+	} else {
+		for _, element := range tree.midSortedByStart {
+			if match(element) {
+				return element
+			}
+		}
+		return nil
+	}
+}
+
+// dataEquals function returns a matcher for findRecord comparing record data via reflect.DeepEqual.
+func dataEquals(data interface{}) func(r *record) bool {
+	return func(r *record) bool {
+		return reflect.DeepEqual(r.Data, data)
+	}
+}
+
+// UpdateData method replaces the data of the interval matching given coordinates and oldData (compared via
+// reflect.DeepEqual) by newData in place, no Sort is needed afterwards. It returns false if no such interval
+// is found. Intervals added by AddMulti keep their values managed by it and are never matched.
+func (tree *IntervalTree) UpdateData(start int, end int, oldData interface{}, newData interface{}) bool {
+	matches := dataEquals(oldData)
+	r := tree.findRecord(start, end, func(r *record) bool {
+		return !r.multi && matches(r)
+	})
+	if r == nil {
+		return false
+	}
+	if tree.registry != nil {
+		newData = tree.registry.intern(newData)
+	}
+	r.Data = newData
+	return true
+}
+
+// SoftRemove method marks the interval matching given coordinates and data (compared via reflect.DeepEqual)
+// as deleted without restructuring the tree, tombstoned intervals are invisible to Query, Len and Iter
+// until Compact reclaims them. It returns false if no such interval is found.
+func (tree *IntervalTree) SoftRemove(start int, end int, data interface{}) bool {
+	removed := false
+	if tree.overflows(start, end) {
+		if r := tree.findRecord(start, end, dataEquals(data)); r != nil {
+			r.tombstone = true
+			removed = true
+		}
+	} else if tree.split && tree.config.upper(end) <= tree.center {
+		removed = tree.leftSubtree != nil && tree.leftSubtree.SoftRemove(start, end, data)
+	} else if tree.split && tree.config.lower(start) > tree.center {
+		removed = tree.rightSubtree != nil && tree.rightSubtree.SoftRemove(start, end, data)
+	} else if r := tree.findRecord(start, end, dataEquals(data)); r != nil {
+		r.tombstone = true
+		if tree.split {
+			tree.tombstones++
+		}
+		removed = true
+	}
+	if removed {
+		tree.size--
+	}
+	return removed
+}
+
+// Compact method physically removes all tombstoned intervals from the tree and re-sorts it. With
+// WithDataInterning, the data values no longer carried by any interval are dropped from the registry as well.
+func (tree *IntervalTree) Compact() {
+	tree.compact()
+	tree.rebuildRegistry()
+	tree.Sort()
+}
+
+// compact method is a technical method used inside Compact for recursive removal.
+func (tree *IntervalTree) compact() {
+	tree.index = nil
+	if !tree.split {
+		if tree.singleInterval != nil && tree.singleInterval.tombstone {
+			tree.singleInterval = nil
+		}
+		return
+	}
+	if tree.tombstones > 0 {
+		tree.midSortedByStart = removeTombstoned(tree.midSortedByStart)
+		tree.midSortedByEnd = removeTombstoned(tree.midSortedByEnd)
+		tree.tombstones = 0
+	}
+	if len(tree.overflow) > 0 {
+		tree.overflow = removeTombstoned(tree.overflow)
+	}
+	if tree.leftSubtree != nil {
+		tree.leftSubtree.compact()
+	}
+	if tree.rightSubtree != nil {
+		tree.rightSubtree.compact()
+	}
+}
+
+// removeTombstoned function filters tombstoned records out of a slice in place preserving their order.
+func removeTombstoned(records []*record) []*record {
+	result := records[:0]
+	for _, r := range records {
+		if !r.tombstone {
+			result = append(result, r)
+		}
+	}
+	for i := len(result); i < len(records); i++ {
+		records[i] = nil
+	}
+	return result
+}
+
+// CompactSlices method reallocates the mid lists of every node to exactly fit their length, reclaiming the
+// capacity left behind by removals. Neither the intervals nor their order are changed.
+func (tree *IntervalTree) CompactSlices() {
+	if !tree.split {
+		return
+	}
+	tree.midSortedByStart = fitRecords(tree.midSortedByStart)
+	tree.midSortedByEnd = fitRecords(tree.midSortedByEnd)
+	tree.overflow = fitRecords(tree.overflow)
+	if tree.leftSubtree != nil {
+		tree.leftSubtree.CompactSlices()
+	}
+	if tree.rightSubtree != nil {
+		tree.rightSubtree.CompactSlices()
+	}
+}
+
+// fitRecords function returns a copy of a slice whose capacity equals its length, empty slices become nil.
+func fitRecords(records []*record) []*record {
+	if len(records) == cap(records) {
+		return records
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	result := make([]*record, len(records))
+	copy(result, records)
+	return result
+}
+
+// distanceTo function returns the distance from point x to the interval [start, end), i.e. zero if the interval
+// overlaps x, start - x if the interval lies to the right of x and x - end + 1 if it lies to the left of x.
+func distanceTo(x int, start int, end int) int {
+	if x < start {
+		return start - x
+	} else if x >= end {
+		return x - end + 1
+	}
+	return 0
+}
+
+// nearestItem struct holds an interval along with its distance to the query point.
+type nearestItem struct {
+	interval Interval
+	distance int
+}
+
+// nearestHeap type implements heap.Interface as a max-heap of nearestItem ordered by distance and start.
+type nearestHeap []nearestItem
+
+func (h nearestHeap) Len() int { return len(h) }
+func (h nearestHeap) Less(i, j int) bool {
+	if h[i].distance != h[j].distance {
+		return h[i].distance > h[j].distance
+	}
+	return h[i].interval.Start > h[j].interval.Start
+}
+func (h nearestHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nearestHeap) Push(x interface{}) { *h = append(*h, x.(nearestItem)) }
+func (h *nearestHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// KNearest method returns up to k intervals closest to point x ordered by ascending distance (see distanceTo),
+// ties being broken by start. Intervals overlapping x have zero distance.
+func (tree *IntervalTree) KNearest(x int, k int) []Interval {
+	if k <= 0 {
+		return nil
+	}
+	h := &nearestHeap{}
+	for _, interval := range tree.intervals() {
+		item := nearestItem{interval: interval, distance: distanceTo(x, interval.Start, interval.End)}
+		if h.Len() < k {
+			heap.Push(h, item)
+		} else if (*h)[0].distance > item.distance ||
+			((*h)[0].distance == item.distance && (*h)[0].interval.Start > item.interval.Start) {
+			(*h)[0] = item
+			heap.Fix(h, 0)
+		}
+	}
+	result := make([]Interval, h.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(h).(nearestItem).interval
+	}
+	return result
+}
+
+// QueryByProximity method returns all intervals maintained in the tree ordered by ascending distance from point x
+// (see distanceTo), ties being broken by the smallest start. Overlapping intervals come first at distance zero.
+func (tree *IntervalTree) QueryByProximity(x int) []Interval {
+	result := tree.intervals()
+	sort.SliceStable(result, func(i, j int) bool {
+		di, dj := distanceTo(x, result[i].Start, result[i].End), distanceTo(x, result[j].Start, result[j].End)
+		if di != dj {
+			return di < dj
+		}
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
+
+// MultiInterval struct defines a single [Start, End) interval carrying several data values, see AddMulti.
+type MultiInterval struct {
+	Start int
+	End   int
+	Data  []interface{}
+}
+
+// AddMulti method adds data values to the interval [start, end) storing them within a single interval whose
+// data is the slice of values. Subsequent calls for the same range extend the value list of the existing
+// interval instead of adding a new one. As with AddInterval, Sort must be invoked after adding new ranges, and
+// ranges covering no points are skipped, an error is returned for them only if WithRejectDegenerate is set.
+func (tree *IntervalTree) AddMulti(start int, end int, data ...interface{}) error {
+	if !tree.config.valid(start, end) {
+		if tree.config.rejectDegenerate {
+			return degenerateError(start, end)
+		}
+		return nil
+	}
+	isMulti := func(r *record) bool {
+		return r.multi
+	}
+	if r := tree.findRecord(start, end, isMulti); r != nil {
+		r.Data = append(r.Data.([]interface{}), data...)
+		return nil
+	}
+	r := &record{Interval: Interval{Start: start, End: end, Data: append([]interface{}{}, data...)}, multi: true}
+	tree.numberInsert(r)
+	depth := tree.addInterval(r)
+	tree.maybeRebalance(depth)
+	if tree.observer != nil {
+		tree.observer.OnInsert(start, end)
+	}
+	return nil
+}
+
+// QueryMulti method returns all intervals added via AddMulti which overlap given point x. The value lists are
+// copies, so they are not affected by later calls of AddMulti and changing them does not change the tree.
+func (tree *IntervalTree) QueryMulti(x int) []MultiInterval {
+	var result []MultiInterval
+	for _, r := range tree.query(x) {
+		if r.multi {
+			data := append([]interface{}(nil), r.Data.([]interface{})...)
+			result = append(result, MultiInterval{Start: r.Start, End: r.End, Data: data})
+		}
+	}
+	return result
+}
+
+// IsPartition method reports whether the intervals maintained in the tree exactly tile [min, max) of the tree,
+// i.e. are contiguous, never overlap and cover the whole range, an empty tree is not a partition.
+func (tree *IntervalTree) IsPartition() bool {
+	intervals := tree.IterSorted()
+	if len(intervals) == 0 || intervals[0].Start != tree.min || intervals[len(intervals)-1].End != tree.max {
+		return false
+	}
+	for i := 0; i < len(intervals)-1; i++ {
+		if intervals[i].End != intervals[i+1].Start {
+			return false
+		}
+	}
+	return true
+}
+
+// Checksum method returns a stable FNV-1a hash over the sorted (start, end, fmt.Sprintf("%v", data)) sequence
+// of intervals maintained in the tree, neither insertion order nor tree shape affect the value.
+func (tree *IntervalTree) Checksum() uint64 {
+	intervals := tree.IterSorted()
+	formatted := make([]string, len(intervals))
+	for i, interval := range intervals {
+		formatted[i] = fmt.Sprintf("%v", interval.Data)
+	}
+	sort.Sort(byIntervalAndData{intervals: intervals, formatted: formatted})
+	hash := fnv.New64a()
+	buffer := make([]byte, 8)
+	for i, interval := range intervals {
+		binary.BigEndian.PutUint64(buffer, uint64(interval.Start))
+		hash.Write(buffer)
+		binary.BigEndian.PutUint64(buffer, uint64(interval.End))
+		hash.Write(buffer)
+		binary.BigEndian.PutUint64(buffer, uint64(len(formatted[i])))
+		hash.Write(buffer)
+		hash.Write([]byte(formatted[i]))
+	}
+	return hash.Sum64()
+}
+
+// byIntervalAndData type implements sort.Interface ordering intervals by start, end and formatted data.
+type byIntervalAndData struct {
+	intervals []Interval
+	formatted []string
+}
+
+func (s byIntervalAndData) Len() int { return len(s.intervals) }
+func (s byIntervalAndData) Less(i, j int) bool {
+	if s.intervals[i].Start != s.intervals[j].Start {
+		return s.intervals[i].Start < s.intervals[j].Start
+	}
+	if s.intervals[i].End != s.intervals[j].End {
+		return s.intervals[i].End < s.intervals[j].End
+	}
+	return s.formatted[i] < s.formatted[j]
+}
+func (s byIntervalAndData) Swap(i, j int) {
+	s.intervals[i], s.intervals[j] = s.intervals[j], s.intervals[i]
+	s.formatted[i], s.formatted[j] = s.formatted[j], s.formatted[i]
+}
@@ -1,6 +1,7 @@
 package gointervaltree
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	_ "github.com/stretchr/testify/assert"
 	"sort"
@@ -56,4 +57,721 @@ func doTest(min int, max int, intervals [][]int, queryPoints []int, assert *asse
 	}
 	assert.Equal(expectedLength, observedLength)
 	assert.Equal(expectedLength, len(tree.Iter()))
+	assertSizes(tree, assert)
+}
+
+func assertSizes(tree *IntervalTree, assert *assert.Assertions) {
+	assert.Equal(tree.Len(), tree.size)
+	if tree.leftSubtree != nil {
+		assertSizes(tree.leftSubtree, assert)
+	}
+	if tree.rightSubtree != nil {
+		assertSizes(tree.rightSubtree, assert)
+	}
+}
+
+func TestKNearest(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, "a")
+	tree.AddInterval(22, 25, "b")
+	tree.AddInterval(48, 52, "c")
+	tree.AddInterval(45, 50, "d")
+	tree.AddInterval(53, 55, "e")
+	tree.AddInterval(40, 44, "f")
+	tree.Sort()
+	result := tree.KNearest(50, 3)
+	assert.Equal([]Interval{{48, 52, "c"}, {45, 50, "d"}, {53, 55, "e"}}, result)
+	result = tree.KNearest(50, 10)
+	assert.Equal(6, len(result))
+	assert.Equal([]Interval{{48, 52, "c"}, {45, 50, "d"}, {53, 55, "e"}, {40, 44, "f"}, {22, 25, "b"},
+		{10, 20, "a"}}, result)
+	assert.Empty(tree.KNearest(50, 0))
+	assert.Empty(NewIntervalTree(0, 100).KNearest(50, 5))
+}
+
+func TestQueryByProximity(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.QueryByProximity(50))
+	tree.AddInterval(10, 20, "a")
+	tree.AddInterval(22, 25, "b")
+	tree.AddInterval(48, 52, "c")
+	tree.AddInterval(45, 51, "d")
+	tree.AddInterval(53, 55, "e")
+	tree.AddInterval(40, 44, "f")
+	tree.AddInterval(60, 90, "g")
+	tree.Sort()
+	assert.Equal([]Interval{{45, 51, "d"}, {48, 52, "c"}, {53, 55, "e"}, {40, 44, "f"}, {60, 90, "g"},
+		{22, 25, "b"}, {10, 20, "a"}}, tree.QueryByProximity(50))
+	assert.Equal(tree.KNearest(50, 3), tree.QueryByProximity(50)[:3])
+}
+
+type recordingObserver struct {
+	inserts [][2]int
+	queries [][2]int
+}
+
+func (o *recordingObserver) OnInsert(start int, end int) {
+	o.inserts = append(o.inserts, [2]int{start, end})
+}
+
+func (o *recordingObserver) OnQuery(x int, matched int) {
+	o.queries = append(o.queries, [2]int{x, matched})
+}
+
+func TestObserver(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	observer := &recordingObserver{}
+	tree.SetObserver(observer)
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(15, 60, nil)
+	tree.AddInterval(70, 80, nil)
+	tree.AddInterval(30, 30, nil)
+	tree.Sort()
+	tree.Query(16)
+	tree.Query(75)
+	tree.Query(95)
+	assert.Equal([][2]int{{10, 20}, {15, 60}, {70, 80}}, observer.inserts)
+	assert.Equal([][2]int{{16, 2}, {75, 1}, {95, 0}}, observer.queries)
+	tree.SetObserver(nil)
+	tree.AddInterval(1, 2, nil)
+	tree.Query(1)
+	assert.Equal(3, len(observer.inserts))
+	assert.Equal(3, len(observer.queries))
+}
+
+func TestSoftRemove(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, "a")
+	tree.AddInterval(15, 60, "b")
+	tree.AddInterval(40, 55, "c")
+	tree.AddInterval(70, 80, "d")
+	tree.Sort()
+	assert.True(tree.SoftRemove(15, 60, "b"))
+	assert.True(tree.SoftRemove(70, 80, "d"))
+	assert.False(tree.SoftRemove(15, 60, "b"))
+	assert.False(tree.SoftRemove(10, 20, "x"))
+	assert.Equal([]interface{}{[]interface{}{10, 20, "a"}}, tree.Query(16))
+	assert.Equal([]interface{}{[]interface{}{40, 55, "c"}}, tree.Query(50))
+	assert.Empty(tree.Query(75))
+	assert.Equal(2, tree.Len())
+	assert.Equal(2, len(tree.Iter()))
+	tree.Compact()
+	assert.Equal(1, len(tree.midSortedByStart))
+	assert.Equal(1, len(tree.midSortedByEnd))
+	assert.Equal(0, tree.tombstones)
+	assert.Equal(2, tree.Len())
+	assert.Equal([]interface{}{[]interface{}{10, 20, "a"}}, tree.Query(16))
+	tree.AddInterval(15, 60, "b")
+	tree.Sort()
+	assert.Equal(3, tree.Len())
+	assert.Equal(2, len(tree.Query(16)))
+	assertSizes(tree, assert)
+}
+
+func TestUpdateData(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, "a")
+	tree.AddInterval(40, 60, []int{1})
+	tree.AddInterval(40, 60, "b")
+	tree.Sort()
+	assert.True(tree.UpdateData(40, 60, []int{1}, []int{2}))
+	assert.ElementsMatch([]interface{}{[]interface{}{40, 60, []int{2}}, []interface{}{40, 60, "b"}}, tree.Query(50))
+	assert.False(tree.UpdateData(40, 60, []int{1}, []int{3}))
+	assert.False(tree.UpdateData(10, 21, "a", "c"))
+	assert.True(tree.UpdateData(10, 20, "a", "c"))
+	assert.Equal([]interface{}{[]interface{}{10, 20, "c"}}, tree.Query(15))
+	assert.Equal(3, tree.Len())
+	assertSorted(tree, assert)
+	tree.AddMulti(70, 80, "a", "b")
+	tree.Sort()
+	assert.False(tree.UpdateData(70, 80, []interface{}{"a", "b"}, "z"))
+	assert.Equal([]MultiInterval{{70, 80, []interface{}{"a", "b"}}}, tree.QueryMulti(75))
+	tree.AddMulti(70, 80, "c")
+	assert.Equal([]MultiInterval{{70, 80, []interface{}{"a", "b", "c"}}}, tree.QueryMulti(75))
+}
+
+func TestAddMulti(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddMulti(10, 60, "a", "b")
+	tree.AddMulti(20, 30, "c")
+	tree.AddInterval(10, 60, "d")
+	tree.AddMulti(10, 60, "e")
+	tree.Sort()
+	assert.Equal(3, tree.Len())
+	assert.Equal([]MultiInterval{{10, 60, []interface{}{"a", "b", "e"}}}, tree.QueryMulti(15))
+	result := tree.QueryMulti(25)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start > result[j].Start
+	})
+	assert.Equal([]MultiInterval{{20, 30, []interface{}{"c"}}, {10, 60, []interface{}{"a", "b", "e"}}}, result)
+	assert.Empty(tree.QueryMulti(70))
+	returned := tree.QueryMulti(15)[0].Data
+	returned[0] = "changed"
+	assert.NoError(tree.AddMulti(10, 60, "f"))
+	assert.Equal([]interface{}{"changed", "b", "e"}, returned)
+	assert.Equal([]MultiInterval{{10, 60, []interface{}{"a", "b", "e", "f"}}}, tree.QueryMulti(15))
+	assert.NoError(tree.AddMulti(40, 40, "empty"))
+	rejecting := NewIntervalTree(0, 100, WithRejectDegenerate())
+	assert.ErrorIs(rejecting.AddMulti(40, 40, "empty"), ErrDegenerateInterval)
+	assert.Equal(0, rejecting.Len())
+}
+
+func TestQueryRange(t *testing.T) {
+	assert := assert.New(t)
+	intervals := [][]int{{10, 20}, {20, 30}, {21, 31}, {30, 40}, {45, 55}, {45, 56}, {46, 57}, {55, 56}, {58, 59},
+		{50, 51}, {0, 100}, {60, 99}}
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range intervals {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	for low := -5; low < 105; low += 3 {
+		for high := low + 1; high < 110; high += 7 {
+			var expected []Interval
+			for _, interval := range intervals {
+				if interval[0] < high && low < interval[1] {
+					expected = append(expected, Interval{interval[0], interval[1], nil})
+				}
+			}
+			assert.ElementsMatch(expected, tree.QueryRange(low, high))
+		}
+	}
+	assert.Empty(tree.QueryRange(30, 30))
+}
+
+func TestQueryRangeWithOverlap(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(22, 28, "inside")
+	tree.AddInterval(15, 25, "partial")
+	tree.AddInterval(10, 90, "enclosing")
+	tree.AddInterval(60, 70, "outside")
+	tree.Sort()
+	result := tree.QueryRangeWithOverlap(20, 30)
+	assert.ElementsMatch([]IntervalOverlap{
+		{Interval{22, 28, "inside"}, 6},
+		{Interval{15, 25, "partial"}, 5},
+		{Interval{10, 90, "enclosing"}, 10},
+	}, result)
+}
+
+func TestTotalOverlapLength(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Equal(0, tree.TotalOverlapLength(0, 100))
+	tree.AddInterval(22, 28, "inside")
+	tree.AddInterval(15, 25, "partial")
+	tree.AddInterval(10, 90, "enclosing")
+	tree.AddInterval(60, 70, "outside")
+	tree.Sort()
+	assert.Equal(21, tree.TotalOverlapLength(20, 30))
+	assert.Equal(106, tree.TotalOverlapLength(0, 100))
+	assert.Equal(0, tree.TotalOverlapLength(30, 30))
+}
+
+func TestIterSorted(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(60, 70, nil)
+	tree.AddInterval(10, 90, nil)
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(45, 55, nil)
+	tree.Sort()
+	assert.Equal([]Interval{{10, 20, nil}, {10, 90, nil}, {45, 55, nil}, {60, 70, nil}}, tree.IterSorted())
+	assert.Empty(NewIntervalTree(0, 100).IterSorted())
+}
+
+func TestIterInsertionOrder(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100, WithInsertionOrder())
+	inserted := []Interval{{70, 80, "first"}, {10, 20, "second"}, {40, 60, "third"}, {45, 50, "fourth"},
+		{0, 5, "fifth"}, {90, 99, "sixth"}}
+	for _, interval := range inserted {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.AddMulti(30, 35, "seventh")
+	tree.Sort()
+	expected := append(inserted, Interval{30, 35, []interface{}{"seventh"}})
+	assert.Equal(expected, tree.IterInsertionOrder())
+	tree.Rebalance()
+	tree.RemoveByCoords(45, 50)
+	assert.Equal(append(expected[:3:3], expected[4:]...), tree.IterInsertionOrder())
+	plain := NewIntervalTree(0, 100)
+	for _, interval := range inserted {
+		plain.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	plain.Sort()
+	assert.Equal(plain.intervals(), plain.IterInsertionOrder())
+	assert.Equal(0, plain.insertions)
+}
+
+func TestIterBatched(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for i := 0; i < 23; i++ {
+		tree.AddInterval(i*3, i*3+10, i)
+	}
+	tree.SoftRemove(0, 10, 0)
+	tree.Sort()
+	var sizes []int
+	var concatenated []interface{}
+	assert.NoError(tree.IterBatched(5, func(batch []Interval) error {
+		sizes = append(sizes, len(batch))
+		for _, interval := range batch {
+			concatenated = append(concatenated, []interface{}{interval.Start, interval.End, interval.Data})
+		}
+		return nil
+	}))
+	assert.Equal([]int{5, 5, 5, 5, 2}, sizes)
+	assert.Equal(tree.Iter(), concatenated)
+	calls := 0
+	failing := errors.New("failing batch")
+	assert.ErrorIs(tree.IterBatched(4, func(batch []Interval) error {
+		calls++
+		if calls == 2 {
+			return failing
+		}
+		return nil
+	}), failing)
+	assert.Equal(2, calls)
+	calls = 0
+	assert.NoError(NewIntervalTree(0, 100).IterBatched(4, func(batch []Interval) error {
+		calls++
+		return nil
+	}))
+	assert.Equal(0, calls)
+	assert.Panics(func() { tree.IterBatched(0, func([]Interval) error { return nil }) })
+}
+
+func TestIsPartition(t *testing.T) {
+	assert := assert.New(t)
+	build := func(intervals [][]int) *IntervalTree {
+		tree := NewIntervalTree(0, 100)
+		for _, interval := range intervals {
+			tree.AddInterval(interval[0], interval[1], nil)
+		}
+		tree.Sort()
+		return tree
+	}
+	assert.True(build([][]int{{50, 100}, {0, 10}, {10, 50}}).IsPartition())
+	assert.True(build([][]int{{0, 100}}).IsPartition())
+	assert.False(build([][]int{{0, 10}, {20, 100}}).IsPartition())
+	assert.False(build([][]int{{0, 60}, {50, 100}}).IsPartition())
+	assert.False(build([][]int{{0, 50}, {50, 90}}).IsPartition())
+	assert.False(build([][]int{{0, 50}, {0, 50}, {50, 100}}).IsPartition())
+	assert.False(build(nil).IsPartition())
+}
+
+func TestChecksum(t *testing.T) {
+	assert := assert.New(t)
+	intervals := []Interval{{10, 20, "a"}, {15, 60, "b"}, {15, 60, "c"}, {70, 80, nil}, {40, 55, 3}}
+	first := NewIntervalTree(0, 100)
+	for _, interval := range intervals {
+		first.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	first.Sort()
+	second := NewIntervalTree(0, 100)
+	for i := len(intervals) - 1; i >= 0; i-- {
+		second.AddInterval(intervals[i].Start, intervals[i].End, intervals[i].Data)
+	}
+	second.Sort()
+	assert.Equal(first.Checksum(), second.Checksum())
+	third := NewIntervalTree(0, 1000)
+	for _, interval := range intervals {
+		third.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	third.Sort()
+	assert.Equal(first.Checksum(), third.Checksum())
+	changed := NewIntervalTree(0, 100)
+	for _, interval := range intervals[1:] {
+		changed.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	changed.AddInterval(10, 21, "a")
+	changed.Sort()
+	assert.NotEqual(first.Checksum(), changed.Checksum())
+	assert.NotEqual(first.Checksum(), NewIntervalTree(0, 100).Checksum())
+}
+
+func TestUndo(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.False(tree.Undo())
+	tree.AddInterval(10, 20, "a")
+	tree.AddInterval(15, 60, "b")
+	tree.AddInterval(40, 55, "c")
+	tree.Sort()
+	tree.AddInterval(30, 70, "d")
+	tree.Sort()
+	assert.Equal(4, tree.Len())
+	assert.True(tree.Undo())
+	assert.Equal(3, tree.Len())
+	assert.ElementsMatch([]interface{}{[]interface{}{15, 60, "b"}, []interface{}{40, 55, "c"}}, tree.Query(50))
+	assert.Equal([]interface{}{[]interface{}{10, 20, "a"}, []interface{}{15, 60, "b"}}, tree.Query(16))
+	assert.True(tree.SoftRemove(40, 55, "c"))
+	tree.Compact()
+	assert.True(tree.Undo())
+	assert.Equal([]Interval{{10, 20, "a"}}, tree.IterSorted())
+	assert.True(tree.Undo())
+	assert.False(tree.Undo())
+	assert.Equal(0, tree.Len())
+	for i := 0; i < 40; i++ {
+		tree.AddInterval(i, i+1, nil)
+	}
+	for i := 0; i < 32; i++ {
+		assert.True(tree.Undo())
+	}
+	assert.False(tree.Undo())
+	assert.Equal(8, tree.Len())
+	assertSizes(tree, assert)
+	soft := NewIntervalTree(0, 100)
+	soft.AddInterval(10, 20, "a")
+	soft.AddInterval(30, 40, "b")
+	soft.Sort()
+	assert.True(soft.SoftRemove(30, 40, "b"))
+	assert.True(soft.Undo())
+	assert.Equal(0, soft.Len())
+	assert.Empty(soft.Iter())
+	assert.False(soft.Undo())
+}
+
+func TestWithEagerSort(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100, WithEagerSort())
+	intervals := [][]int{{45, 55}, {10, 20}, {40, 60}, {48, 52}, {20, 30}, {49, 90}, {1, 99}, {50, 51}, {30, 70}}
+	for i, interval := range intervals {
+		tree.AddInterval(interval[0], interval[1], nil)
+		for q := 0; q < 100; q++ {
+			var expected []interface{}
+			for _, added := range intervals[:i+1] {
+				if added[0] <= q && q < added[1] {
+					expected = append(expected, []interface{}{added[0], added[1], nil})
+				}
+			}
+			assert.ElementsMatch(expected, tree.Query(q))
+		}
+	}
+}
+
+func BenchmarkEagerSortInsert(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		tree := NewIntervalTree(0, 10000, WithEagerSort())
+		for i := 0; i < 1000; i++ {
+			tree.AddInterval(5000-i*5, 5000+i*3, nil)
+		}
+	}
+}
+
+func BenchmarkBatchInsertSort(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		tree := NewIntervalTree(0, 10000)
+		for i := 0; i < 1000; i++ {
+			tree.AddInterval(5000-i*5, 5000+i*3, nil)
+		}
+		tree.Sort()
+	}
+}
+
+func TestRemoveByCoords(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(40, 60, "a")
+	tree.AddInterval(40, 60, func() {})
+	tree.AddInterval(40, 60, make(chan int))
+	tree.AddInterval(40, 61, "b")
+	tree.AddInterval(10, 20, "c")
+	tree.AddInterval(10, 20, "d")
+	tree.Sort()
+	assert.Equal(3, tree.RemoveByCoords(40, 60))
+	assert.Equal(0, tree.RemoveByCoords(40, 60))
+	assert.Equal([]interface{}{[]interface{}{40, 61, "b"}}, tree.Query(50))
+	assert.Equal(2, tree.RemoveByCoords(10, 20))
+	assert.Equal(1, tree.Len())
+	assert.Empty(tree.Query(15))
+	single := NewIntervalTree(0, 100)
+	single.AddInterval(1, 2, nil)
+	assert.Equal(0, single.RemoveByCoords(1, 3))
+	assert.Equal(1, single.RemoveByCoords(1, 2))
+	assert.Equal(0, single.Len())
+	assertSizes(tree, assert)
+}
+
+func TestCompactSlices(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for i := 0; i < 200; i++ {
+		tree.AddInterval(40, 60+i%20, i)
+	}
+	tree.AddInterval(10, 20, "left")
+	tree.AddInterval(10, 11, "left")
+	tree.Sort()
+	for i := 1; i < 20; i++ {
+		tree.RemoveByCoords(40, 60+i)
+	}
+	before := cap(tree.midSortedByStart)
+	order := tree.Iter()
+	tree.CompactSlices()
+	assert.Equal(10, len(tree.midSortedByStart))
+	assert.Equal(10, cap(tree.midSortedByStart))
+	assert.Equal(10, cap(tree.midSortedByEnd))
+	assert.Less(cap(tree.midSortedByStart), before)
+	assert.Equal(order, tree.Iter())
+	assert.Equal(10, len(tree.Query(50)))
+	assert.Equal(1, len(tree.Query(15)))
+	assertSorted(tree, assert)
+	tree.RemoveByCoords(40, 60)
+	tree.CompactSlices()
+	assert.Nil(tree.midSortedByStart)
+	assert.Equal(2, len(tree.Query(10)))
+}
+
+func TestAddIntervals(t *testing.T) {
+	assert := assert.New(t)
+	intervals := []Interval{{10, 20, "a"}, {20, 30, "b"}, {45, 55, "c"}, {46, 57, "d"}, {55, 56, "e"}, {0, 100, "f"},
+		{30, 30, "empty"}}
+	bulk := NewIntervalTree(0, 100)
+	bulk.AddIntervals(intervals)
+	single := NewIntervalTree(0, 100)
+	for _, interval := range intervals {
+		single.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	single.Sort()
+	assert.Equal(single.Len(), bulk.Len())
+	for q := -1; q <= 100; q++ {
+		assert.Equal(single.Query(q), bulk.Query(q))
+	}
+}
+
+func TestMergeIntervals(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, "old")
+	tree.Sort()
+	assert.NoError(tree.MergeIntervals([]Interval{{15, 30, "new"}, {50, 60, "new"}}))
+	assert.Equal(3, tree.Len())
+	assert.ElementsMatch([]Interval{{10, 20, "old"}, {15, 30, "new"}}, tree.queryIntervals(17))
+	assert.Equal(1, len(tree.Query(55)))
+	assertSorted(tree, assert)
+	err := tree.MergeIntervals([]Interval{{70, 80, nil}, {90, 101, nil}})
+	assert.ErrorIs(err, ErrOutOfBounds)
+	err = tree.MergeIntervals([]Interval{{70, 80, nil}, {40, 40, nil}})
+	assert.ErrorIs(err, ErrDegenerateInterval)
+	assert.Equal(3, tree.Len())
+	assert.Empty(tree.Query(75))
+	closed := NewIntervalTree(0, 100, WithIntervalMode(Closed))
+	assert.NoError(closed.MergeIntervals([]Interval{{40, 40, nil}, {0, 99, nil}}))
+	assert.ErrorIs(closed.MergeIntervals([]Interval{{0, 100, nil}}), ErrOutOfBounds)
+}
+
+func TestFromMap(t *testing.T) {
+	assert := assert.New(t)
+	tree, err := FromMap(0, 100, map[[2]int]interface{}{{10, 30}: "a", {20, 60}: "b", {70, 80}: nil, {20, 25}: 4})
+	assert.NoError(err)
+	assert.Equal(4, tree.Len())
+	assert.Equal([]Interval{{10, 30, "a"}, {20, 25, 4}, {20, 60, "b"}, {70, 80, nil}}, tree.IterSorted())
+	assert.ElementsMatch([]interface{}{[]interface{}{10, 30, "a"}, []interface{}{20, 60, "b"},
+		[]interface{}{20, 25, 4}}, tree.Query(22))
+	assert.Equal([]interface{}{[]interface{}{70, 80, nil}}, tree.Query(75))
+	empty, err := FromMap(0, 100, nil)
+	assert.NoError(err)
+	assert.Equal(0, empty.Len())
+	_, err = FromMap(0, 100, map[[2]int]interface{}{{10, 30}: "a", {90, 110}: "b"})
+	assert.ErrorIs(err, ErrOutOfBounds)
+	_, err = FromMap(0, 100, map[[2]int]interface{}{{10, 30}: "a", {40, 40}: "b"})
+	assert.ErrorIs(err, ErrDegenerateInterval)
+}
+
+func TestAddIntervalsCollectErrors(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.AddIntervalsCollectErrors([]Interval{{10, 20, "a"}}))
+	errs := tree.AddIntervalsCollectErrors([]Interval{{30, 40, "b"}, {-5, 10, nil}, {50, 50, nil}, {60, 70, "c"},
+		{90, 101, nil}, {80, 70, nil}})
+	if assert.Equal(4, len(errs)) {
+		assert.ErrorIs(errs[0], ErrOutOfBounds)
+		assert.ErrorIs(errs[1], ErrDegenerateInterval)
+		assert.ErrorIs(errs[2], ErrOutOfBounds)
+		assert.ErrorIs(errs[3], ErrDegenerateInterval)
+		assert.Contains(errs[0].Error(), "interval 1")
+		assert.NotEqual(errs[1].Error(), errs[3].Error())
+	}
+	assert.Equal(3, tree.Len())
+	assert.Equal(1, len(tree.Query(35)))
+	assert.Equal(1, len(tree.Query(65)))
+	assertSorted(tree, assert)
+}
+
+func TestWithRejectDegenerate(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.NoError(tree.AddInterval(10, 20, nil))
+	assert.NoError(tree.AddInterval(30, 30, nil))
+	assert.Equal(1, tree.Len())
+	strict := NewIntervalTree(0, 100, WithRejectDegenerate())
+	assert.NoError(strict.AddInterval(10, 20, nil))
+	assert.ErrorIs(strict.AddInterval(30, 30, nil), ErrDegenerateInterval)
+	assert.ErrorIs(strict.AddInterval(40, 35, nil), ErrDegenerateInterval)
+	assert.Equal(1, strict.Len())
+	assertSizes(strict, assert)
+	closed := NewIntervalTree(0, 100, WithRejectDegenerate(), WithIntervalMode(Closed))
+	assert.NoError(closed.AddInterval(30, 30, nil))
+	assert.Equal(1, closed.Len())
+}
+
+func TestWithIntervalMode(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		mode     IntervalMode
+		first    int
+		last     int
+		singular bool
+	}{
+		{ClosedOpen, 10, 19, false},
+		{OpenClosed, 11, 20, false},
+		{Closed, 10, 20, true},
+		{Open, 11, 19, false},
+	}
+	for _, c := range cases {
+		tree := NewIntervalTree(0, 100, WithIntervalMode(c.mode))
+		tree.AddInterval(10, 20, "a")
+		tree.AddInterval(30, 30, "point")
+		tree.AddInterval(40, 41, "unit")
+		tree.Sort()
+		for x := 8; x <= 22; x++ {
+			expected := 0
+			if c.first <= x && x <= c.last {
+				expected = 1
+			}
+			assert.Equal(expected, len(tree.Query(x)), "mode %d, point %d", c.mode, x)
+		}
+		if c.singular {
+			assert.Equal(3, tree.Len())
+			assert.Equal(1, len(tree.Query(30)))
+		} else if c.mode == Open {
+			assert.Equal(1, tree.Len())
+		} else {
+			assert.Equal(2, tree.Len())
+		}
+		assert.Equal(1, len(tree.QueryRange(c.first, c.first+1)))
+		assert.Equal(0, len(tree.QueryRange(c.first-1, c.first)))
+		assert.Equal(1, len(tree.QueryRange(c.last, c.last+1)))
+		assert.Equal(0, len(tree.QueryRange(c.last+1, c.last+2)))
+	}
+	tree := NewIntervalTree(0, 100, WithIntervalMode(OpenClosed))
+	tree.AddInterval(40, 50, "a")
+	tree.AddInterval(50, 60, "b")
+	tree.Sort()
+	assert.Equal([]interface{}{[]interface{}{40, 50, "a"}}, tree.Query(50))
+	assert.True(tree.SoftRemove(40, 50, "a"))
+	assert.Empty(tree.Query(50))
+	assert.Equal(1, tree.RemoveByCoords(50, 60))
+	assert.Equal(0, tree.Len())
+}
+
+func TestNegativeBounds(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(-3, NewIntervalTree(-5, 0).center)
+	assert.Equal(-3, NewIntervalTree(-3, -2).center)
+	assert.Equal(0, NewIntervalTree(-100, 100).center)
+	intervals := [][]int{{-3, -2}, {-3, -2}, {-2, -1}, {-1, 0}, {-1, 1}, {0, 1}, {-50, -49}, {-50, -49}, {-100, -99},
+		{-100, -99}, {-5, 5}, {-2, 3}, {1, 2}, {98, 99}}
+	queryPoints := []int{-101, -100, -99, -51, -50, -49, -6, -5, -4, -3, -2, -1, 0, 1, 2, 3, 4, 5, 98, 99}
+	doTest(-100, 100, intervals, queryPoints, assert)
+	doTest(-100, -1, [][]int{{-3, -2}, {-3, -2}, {-2, -1}, {-99, -98}, {-100, -99}}, queryPoints, assert)
+	doTest(-3, -1, [][]int{{-3, -2}, {-3, -2}, {-2, -1}}, queryPoints, assert)
+}
+
+func assertSorted(tree *IntervalTree, assert *assert.Assertions) {
+	assert.False(tree.dirty)
+	assert.False(tree.unsorted && tree.split)
+	assert.True(sort.SliceIsSorted(tree.midSortedByStart, func(i, j int) bool {
+		return tree.midSortedByStart[i].Start < tree.midSortedByStart[j].Start
+	}))
+	assert.True(sort.SliceIsSorted(tree.midSortedByEnd, func(i, j int) bool {
+		return tree.midSortedByEnd[i].End > tree.midSortedByEnd[j].End
+	}))
+	if tree.leftSubtree != nil {
+		assertSorted(tree.leftSubtree, assert)
+	}
+	if tree.rightSubtree != nil {
+		assertSorted(tree.rightSubtree, assert)
+	}
+}
+
+func TestIncrementalSort(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000)
+	var intervals [][]int
+	for i := 0; i < 300; i++ {
+		interval := []int{(i * 37) % 900, (i*37)%900 + 1 + (i*13)%100}
+		intervals = append(intervals, interval)
+		tree.AddInterval(interval[0], interval[1], nil)
+		if i%10 == 0 {
+			tree.Sort()
+			assertSorted(tree, assert)
+			for q := 0; q < 1000; q += 7 {
+				expected := 0
+				for _, added := range intervals {
+					if added[0] <= q && q < added[1] {
+						expected++
+					}
+				}
+				assert.Equal(expected, len(tree.Query(q)))
+			}
+		}
+	}
+	tree.Sort()
+	tree.AddInterval(495, 505, nil)
+	assert.True(tree.dirty)
+	assert.True(tree.unsorted)
+	assert.False(tree.leftSubtree.unsorted && tree.leftSubtree.split)
+	tree.Sort()
+	assertSorted(tree, assert)
+}
+
+func buildLargeTree() *IntervalTree {
+	tree := NewIntervalTree(0, 1000000)
+	for i := 0; i < 100000; i++ {
+		start := (i * 7919) % 990000
+		tree.AddInterval(start, start+1+(i*31)%10000, nil)
+	}
+	tree.Sort()
+	return tree
+}
+
+func BenchmarkSortAfterSingleInsert(b *testing.B) {
+	tree := buildLargeTree()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		tree.AddInterval(n%990000, n%990000+100, nil)
+		tree.Sort()
+	}
+}
+
+func BenchmarkFullSort(b *testing.B) {
+	tree := buildLargeTree()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		tree.Rebalance()
+		tree.markUnsorted()
+		b.StartTimer()
+		tree.Sort()
+	}
+}
+
+func (tree *IntervalTree) markUnsorted() {
+	tree.dirty = true
+	tree.unsorted = true
+	if tree.leftSubtree != nil {
+		tree.leftSubtree.markUnsorted()
+	}
+	if tree.rightSubtree != nil {
+		tree.rightSubtree.markUnsorted()
+	}
 }
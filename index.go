@@ -0,0 +1,66 @@
+package gointervaltree
+
+import "sort"
+
+// WithSortedArrayIndex function returns an Option making Sort also build an array of all intervals sorted by start.
+// A point query then binary searches the array for the intervals starting within the longest interval length
+// before the point and scans only those, which beats the tree traversal for many short intervals. Query picks
+// the array whenever that scan is no longer than a few times the tree height, otherwise it traverses the tree.
+// The array is dropped by any change of the tree and rebuilt by the next Sort.
+func WithSortedArrayIndex() Option {
+	return func(config *treeConfig) {
+		config.sortedArrayIndex = true
+	}
+}
+
+// arrayIndex struct defines the sorted array built by Sort when WithSortedArrayIndex is set.
+type arrayIndex struct {
+	byStart   []*record
+	maxLength int
+	height    int
+}
+
+// newArrayIndex function builds the sorted array over all live records of the tree.
+func newArrayIndex(tree *IntervalTree) *arrayIndex {
+	index := &arrayIndex{byStart: tree.records(), height: tree.Height()}
+	sort.Slice(index.byStart, func(i, j int) bool {
+		return index.byStart[i].Start < index.byStart[j].Start
+	})
+	for _, r := range index.byStart {
+		if length := tree.config.upper(r.End) - tree.config.lower(r.Start); length > index.maxLength {
+			index.maxLength = length
+		}
+	}
+	return index
+}
+
+// window method returns the range of byStart holding every record which may overlap given point,
+// i.e. those with x - maxLength < lower(start) <= x.
+func (index *arrayIndex) window(x int, config *treeConfig) (int, int) {
+	low := sort.Search(len(index.byStart), func(i int) bool {
+		return config.lower(index.byStart[i].Start) > x-index.maxLength
+	})
+	high := sort.Search(len(index.byStart), func(i int) bool {
+		return config.lower(index.byStart[i].Start) > x
+	})
+	return low, high
+}
+
+// queryIndexed method is a technical method answering a point query via the sorted array, it returns false
+// if the array is missing or the heuristic prefers the tree traversal.
+func (tree *IntervalTree) queryIndexed(x int) ([]*record, bool) {
+	if tree.index == nil {
+		return nil, false
+	}
+	low, high := tree.index.window(x, tree.config)
+	if high-low > 4*tree.index.height {
+		return nil, false
+	}
+	var result []*record
+	for _, r := range tree.index.byStart[low:high] {
+		if !r.tombstone && tree.config.contains(r.Interval, x) {
+			result = append(result, r)
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,82 @@
+package gointervaltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSortedArrayIndex(t *testing.T) {
+	assert := assert.New(t)
+	plain := NewIntervalTree(0, 10000)
+	indexed := NewIntervalTree(0, 10000, WithSortedArrayIndex())
+	for i := 0; i < 2000; i++ {
+		start := (i * 7919) % 9900
+		plain.AddInterval(start, start+1+i%40, i)
+		indexed.AddInterval(start, start+1+i%40, i)
+	}
+	assert.Nil(indexed.index)
+	plain.Sort()
+	indexed.Sort()
+	assert.NotNil(indexed.index)
+	used := 0
+	check := func() {
+		for q := 0; q < 10000; q += 3 {
+			if _, ok := indexed.queryIndexed(q); ok {
+				used++
+			}
+			assert.ElementsMatch(plain.Query(q), indexed.Query(q))
+		}
+	}
+	check()
+	assert.Greater(used, 0)
+	plain.SoftRemove(0, 1, 0)
+	indexed.SoftRemove(0, 1, 0)
+	check()
+	plain.RemoveByCoords(7919, 7939)
+	indexed.RemoveByCoords(7919, 7939)
+	assert.Nil(indexed.index)
+	check()
+	plain.AddInterval(0, 9000, "broad")
+	indexed.AddInterval(0, 9000, "broad")
+	assert.Nil(indexed.index)
+	plain.Sort()
+	indexed.Sort()
+	check()
+	_, ok := indexed.queryIndexed(5000)
+	assert.False(ok)
+	assert.Nil(plain.index)
+}
+
+func buildIndexBenchmarkTree(maxLength int, options ...Option) *IntervalTree {
+	tree := NewIntervalTree(0, 1000000, options...)
+	for i := 0; i < 100000; i++ {
+		start := (i * 7919) % 990000
+		tree.AddInterval(start, start+1+(i*31)%maxLength, nil)
+	}
+	tree.Sort()
+	return tree
+}
+
+func benchmarkQuery(b *testing.B, tree *IntervalTree) {
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		tree.query((n * 7919) % 1000000)
+	}
+}
+
+func BenchmarkDenseTreeQuery(b *testing.B) {
+	benchmarkQuery(b, buildIndexBenchmarkTree(50))
+}
+
+func BenchmarkDenseArrayIndexQuery(b *testing.B) {
+	benchmarkQuery(b, buildIndexBenchmarkTree(50, WithSortedArrayIndex()))
+}
+
+func BenchmarkSparseTreeQuery(b *testing.B) {
+	benchmarkQuery(b, buildIndexBenchmarkTree(10000))
+}
+
+func BenchmarkSparseArrayIndexQuery(b *testing.B) {
+	benchmarkQuery(b, buildIndexBenchmarkTree(10000, WithSortedArrayIndex()))
+}
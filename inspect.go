@@ -0,0 +1,105 @@
+package gointervaltree
+
+import "unsafe"
+
+// RootMidIntervals method returns the intervals kept in the mid list of the root node, i.e. those straddling
+// the root center, in the order of midSortedByStart. A root holding a single interval has no mid list.
+func (tree *IntervalTree) RootMidIntervals() []Interval {
+	var result []Interval
+	for _, r := range tree.midSortedByStart {
+		if !r.tombstone {
+			result = append(result, r.Interval)
+		}
+	}
+	return result
+}
+
+// FirstMisplaced method traverses the tree in pre-order and returns the first interval found in a node which
+// cannot contain it, i.e. whose [min, max) does not enclose the interval or, for mid list intervals,
+// whose center the interval does not straddle or, for overflow intervals, whose center the interval straddles,
+// along with that node. False is returned for a consistent tree.
+func (tree *IntervalTree) FirstMisplaced() (Interval, *IntervalTree, bool) {
+	fits := func(r *record) bool {
+		return tree.min <= tree.config.lower(r.Start) && tree.config.upper(r.End) <= tree.max
+	}
+	if !tree.split {
+		if tree.singleInterval != nil && !fits(tree.singleInterval) {
+			return tree.singleInterval.Interval, tree, true
+		}
+		return Interval{}, nil, false
+	}
+	for _, r := range tree.midSortedByStart {
+		if !fits(r) || tree.config.lower(r.Start) > tree.center || tree.config.upper(r.End) <= tree.center {
+			return r.Interval, tree, true
+		}
+	}
+	for _, r := range tree.overflow {
+		if !fits(r) || !tree.overflows(r.Start, r.End) {
+			return r.Interval, tree, true
+		}
+	}
+	for _, subtree := range []*IntervalTree{tree.leftSubtree, tree.rightSubtree} {
+		if subtree != nil {
+			if interval, node, ok := subtree.FirstMisplaced(); ok {
+				return interval, node, true
+			}
+		}
+	}
+	return Interval{}, nil, false
+}
+
+// VisitNodes method traverses the tree in pre-order invoking fn for every node with its bounds, center and
+// the live intervals of its mid list in the order of midSortedByStart. Nodes which are not split, i.e. empty or
+// holding a single interval, are visited with an empty mid list. The mid slice is a copy owned by fn.
+func (tree *IntervalTree) VisitNodes(fn func(min int, max int, center int, mid []Interval)) {
+	var mid []Interval
+	if tree.split {
+		mid = tree.RootMidIntervals()
+	}
+	fn(tree.min, tree.max, tree.center, mid)
+	if tree.leftSubtree != nil {
+		tree.leftSubtree.VisitNodes(fn)
+	}
+	if tree.rightSubtree != nil {
+		tree.rightSubtree.VisitNodes(fn)
+	}
+}
+
+// MemReport struct defines a breakdown of the memory held by a tree as returned by MemoryReport.
+type MemReport struct {
+	// NodeCount is the number of nodes of the tree, see NodeCount.
+	NodeCount int
+	// MidSlots is the total length of the midSortedByStart and midSortedByEnd lists of all nodes.
+	MidSlots int
+	// MidCapacity is the total capacity of these lists, the excess over MidSlots is slack CompactSlices reclaims.
+	MidCapacity int
+	// EstimatedBytes is an estimate of the memory held by the nodes, the lists and the records, not counting
+	// the memory referenced by interval data.
+	EstimatedBytes int
+}
+
+// MemoryReport method returns a MemReport for the tree, tombstoned intervals still occupy their slots.
+func (tree *IntervalTree) MemoryReport() MemReport {
+	var report MemReport
+	records := 0
+	var visit func(node *IntervalTree)
+	visit = func(node *IntervalTree) {
+		report.NodeCount++
+		report.MidSlots += len(node.midSortedByStart) + len(node.midSortedByEnd)
+		report.MidCapacity += cap(node.midSortedByStart) + cap(node.midSortedByEnd)
+		report.EstimatedBytes += cap(node.overflow) * int(unsafe.Sizeof((*record)(nil)))
+		records += len(node.midSortedByStart) + len(node.overflow)
+		if node.singleInterval != nil {
+			records++
+		}
+		for _, subtree := range []*IntervalTree{node.leftSubtree, node.rightSubtree} {
+			if subtree != nil {
+				visit(subtree)
+			}
+		}
+	}
+	visit(tree)
+	report.EstimatedBytes += report.NodeCount*int(unsafe.Sizeof(IntervalTree{})) +
+		report.MidCapacity*int(unsafe.Sizeof((*record)(nil))) + records*int(unsafe.Sizeof(record{}))
+	return report
+}
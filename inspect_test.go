@@ -0,0 +1,111 @@
+package gointervaltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootMidIntervals(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(40, 60, "straddling")
+	assert.Empty(tree.RootMidIntervals())
+	tree.AddInterval(10, 20, "left")
+	tree.AddInterval(50, 51, "at center")
+	tree.AddInterval(45, 50, "ending at center")
+	tree.AddInterval(51, 70, "right")
+	tree.AddInterval(0, 100, "whole")
+	tree.Sort()
+	assert.Equal([]Interval{{0, 100, "whole"}, {40, 60, "straddling"}, {50, 51, "at center"}},
+		tree.RootMidIntervals())
+}
+
+func TestFirstMisplaced(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range [][]int{{10, 20}, {15, 60}, {40, 55}, {70, 80}, {0, 100}, {50, 51}, {75, 76}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	_, _, ok := tree.FirstMisplaced()
+	assert.False(ok)
+	_, _, ok = NewIntervalTree(0, 100).FirstMisplaced()
+	assert.False(ok)
+	corrupted := &record{Interval: Interval{Start: 60, End: 65, Data: "corrupted"}}
+	tree.leftSubtree.singleInterval = corrupted
+	interval, node, ok := tree.FirstMisplaced()
+	assert.True(ok)
+	assert.Equal(Interval{60, 65, "corrupted"}, interval)
+	assert.Same(tree.leftSubtree, node)
+	misplaced := &record{Interval: Interval{Start: 80, End: 81, Data: "not straddling"}}
+	tree.midSortedByStart = append(tree.midSortedByStart, misplaced)
+	interval, node, ok = tree.FirstMisplaced()
+	assert.True(ok)
+	assert.Equal(Interval{80, 81, "not straddling"}, interval)
+	assert.Same(tree, node)
+}
+
+func TestVisitNodes(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range [][]int{{10, 20}, {15, 60}, {40, 55}, {70, 80}, {0, 100}, {50, 51}, {75, 76}, {5, 6}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	var singles func(node *IntervalTree) int
+	singles = func(node *IntervalTree) int {
+		if node == nil {
+			return 0
+		}
+		if !node.split {
+			if node.singleInterval != nil {
+				return 1
+			}
+			return 0
+		}
+		return singles(node.leftSubtree) + singles(node.rightSubtree)
+	}
+	nodes, total := 0, 0
+	var centers []int
+	tree.VisitNodes(func(min int, max int, center int, mid []Interval) {
+		nodes++
+		total += len(mid)
+		centers = append(centers, center)
+		for _, interval := range mid {
+			assert.True(interval.Start <= center && center < interval.End)
+			assert.True(min <= interval.Start && interval.End <= max)
+		}
+	})
+	assert.Equal(tree.Len()-singles(tree), total)
+	assert.Equal(tree.center, centers[0])
+	assert.Greater(nodes, 1)
+}
+
+func TestMemoryReport(t *testing.T) {
+	assert := assert.New(t)
+	empty := NewIntervalTree(0, 100).MemoryReport()
+	assert.Equal(1, empty.NodeCount)
+	assert.Equal(0, empty.MidSlots)
+	assert.Greater(empty.EstimatedBytes, 0)
+	tree := NewIntervalTree(0, 100)
+	for i := 0; i < 200; i++ {
+		tree.AddInterval(40, 60+i%20, i)
+	}
+	tree.AddInterval(10, 20, nil)
+	tree.Sort()
+	full := tree.MemoryReport()
+	assert.Equal(tree.NodeCount(), full.NodeCount)
+	assert.Equal(400, full.MidSlots)
+	for i := 1; i < 20; i++ {
+		tree.RemoveByCoords(40, 60+i)
+	}
+	report := tree.MemoryReport()
+	assert.Equal(20, report.MidSlots)
+	assert.Greater(report.MidCapacity, report.MidSlots)
+	assert.Less(report.EstimatedBytes, full.EstimatedBytes)
+	tree.CompactSlices()
+	compacted := tree.MemoryReport()
+	assert.Equal(compacted.MidSlots, compacted.MidCapacity)
+	assert.Less(compacted.EstimatedBytes, report.EstimatedBytes)
+}
@@ -0,0 +1,90 @@
+package gointervaltree
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithDataInterning function returns an Option making AddInterval replace data equal to the data of an earlier
+// interval by that earlier value, so that all intervals carrying equal data share a single copy of it instead of
+// keeping their own. Values of comparable types are equal if they are ==, so distinct pointers are never merged
+// even if they point to equal values, while values of other types such as slices and maps are compared via
+// reflect.DeepEqual. Intervals keep the shared value itself rather than an index into the registry. Queries
+// return the shared value, which is indistinguishable from the original one unless the caller mutates data passed
+// to the tree. Values stay registered after the intervals carrying them are removed, until Compact drops those no
+// longer held by any interval.
+func WithDataInterning() Option {
+	return func(config *treeConfig) {
+		config.dataInterning = true
+	}
+}
+
+// dataRegistry struct defines the distinct data values kept by a tree with WithDataInterning, comparable values
+// are looked up directly by == while others are bucketed by their printed representation and compared via
+// reflect.DeepEqual.
+type dataRegistry struct {
+	comparable map[interface{}]interface{}
+	buckets    map[string][]interface{}
+}
+
+// intern method returns the registered value equal to data, registering data itself if there is none.
+func (registry *dataRegistry) intern(data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+	if reflect.TypeOf(data).Comparable() && isComparableValue(reflect.ValueOf(data)) {
+		if registry.comparable == nil {
+			registry.comparable = make(map[interface{}]interface{})
+		}
+		if value, ok := registry.comparable[data]; ok {
+			return value
+		}
+		registry.comparable[data] = data
+		return data
+	}
+	if registry.buckets == nil {
+		registry.buckets = make(map[string][]interface{})
+	}
+	key := fmt.Sprintf("%T:%v", data, data)
+	for _, value := range registry.buckets[key] {
+		if reflect.DeepEqual(value, data) {
+			return value
+		}
+	}
+	registry.buckets[key] = append(registry.buckets[key], data)
+	return data
+}
+
+// rebuildRegistry method is a technical method replacing the registry of a tree with WithDataInterning by one
+// holding only the data of the intervals still maintained in the tree.
+func (tree *IntervalTree) rebuildRegistry() {
+	if tree.registry == nil {
+		return
+	}
+	tree.registry = new(dataRegistry)
+	for _, r := range tree.records() {
+		tree.registry.intern(r.Data)
+	}
+}
+
+// isComparableValue function reports whether a value of a comparable type can be used as a map key without
+// panicking, which is not the case for interfaces or arrays holding non-comparable values.
+func isComparableValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Interface:
+		return value.IsNil() || (value.Elem().Type().Comparable() && isComparableValue(value.Elem()))
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if !isComparableValue(value.Field(i)) {
+				return false
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if !isComparableValue(value.Index(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
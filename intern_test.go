@@ -0,0 +1,130 @@
+package gointervaltree
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type internedPayload struct {
+	Name   string
+	Labels [4]int64
+}
+
+func TestWithDataInterning(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000, WithDataInterning())
+	for i := 0; i < 300; i++ {
+		tree.AddInterval(i, i+10, internedPayload{Name: "shared", Labels: [4]int64{int64(i % 3)}})
+		tree.AddInterval(i, i+5, []string{"slice", string(rune('a' + i%2))})
+	}
+	tree.AddInterval(500, 600, nil)
+	tree.AddInterval(500, 600, [1]interface{}{[]int{1}})
+	tree.AddInterval(500, 601, [1]interface{}{[]int{1}})
+	tree.Sort()
+	assert.NotNil(tree.registry)
+	assert.Equal(3, len(tree.registry.comparable))
+	for _, interval := range tree.queryIntervals(150) {
+		switch data := interval.Data.(type) {
+		case internedPayload:
+			assert.Equal("shared", data.Name)
+			assert.Equal(interval.Start%3, int(data.Labels[0]))
+		case []string:
+			assert.Equal([]string{"slice", string(rune('a' + interval.Start%2))}, data)
+		default:
+			assert.Fail("unexpected data", data)
+		}
+	}
+	var mixed []interface{}
+	for _, interval := range tree.queryIntervals(550) {
+		mixed = append(mixed, interval.Data)
+	}
+	assert.ElementsMatch([]interface{}{nil, [1]interface{}{[]int{1}}, [1]interface{}{[]int{1}}}, mixed)
+	var first, second []string
+	for _, interval := range tree.queryIntervals(150) {
+		if data, ok := interval.Data.([]string); ok && data[1] == "a" {
+			if first == nil {
+				first = data
+			} else {
+				second = data
+			}
+		}
+	}
+	if assert.NotNil(second) {
+		assert.Same(&first[0], &second[0])
+	}
+	assert.Nil(NewIntervalTree(0, 10).registry)
+	pointers := NewIntervalTree(0, 10, WithDataInterning())
+	pointers.AddInterval(0, 5, &internedPayload{Name: "p"})
+	pointers.AddInterval(2, 8, &internedPayload{Name: "p"})
+	pointers.Sort()
+	stored := pointers.queryIntervals(3)
+	if assert.Equal(2, len(stored)) {
+		assert.NotSame(stored[0].Data, stored[1].Data)
+	}
+	assert.Equal(2, len(pointers.registry.comparable))
+}
+
+func TestWithDataInterningCompact(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000, WithDataInterning())
+	for i := 0; i < 100; i++ {
+		tree.AddInterval(i, i+10, i)
+		tree.AddInterval(i, i+20, []int{i})
+	}
+	tree.Sort()
+	for i := 0; i < 90; i++ {
+		tree.RemoveByCoords(i, i+10)
+		tree.RemoveByCoords(i, i+20)
+	}
+	assert.True(tree.UpdateData(95, 105, 95, "updated"))
+	assert.Equal(101, len(tree.registry.comparable))
+	tree.Compact()
+	assert.Equal(10, len(tree.registry.comparable))
+	assert.NotContains(tree.registry.comparable, 95)
+	assert.Contains(tree.registry.comparable, "updated")
+	buckets := 0
+	for _, values := range tree.registry.buckets {
+		buckets += len(values)
+	}
+	assert.Equal(10, buckets)
+	tree.AddInterval(500, 520, []int{99})
+	tree.Sort()
+	var shared [][]int
+	for _, interval := range tree.queryIntervals(100) {
+		if data, ok := interval.Data.([]int); ok && data[0] == 99 {
+			shared = append(shared, data)
+		}
+	}
+	for _, interval := range tree.queryIntervals(510) {
+		shared = append(shared, interval.Data.([]int))
+	}
+	if assert.Equal(2, len(shared)) {
+		assert.Same(&shared[0][0], &shared[1][0])
+	}
+}
+
+func benchmarkRetainedMemory(b *testing.B, options ...Option) {
+	for n := 0; n < b.N; n++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		tree := NewIntervalTree(0, 100000, options...)
+		for i := 0; i < 10000; i++ {
+			tree.AddInterval(i, i+10, internedPayload{Name: "shared", Labels: [4]int64{int64(i % 4)}})
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)), "retained-bytes")
+		runtime.KeepAlive(tree)
+	}
+}
+
+func BenchmarkRetainedMemoryPlain(b *testing.B) {
+	benchmarkRetainedMemory(b)
+}
+
+func BenchmarkRetainedMemoryInterned(b *testing.B) {
+	benchmarkRetainedMemory(b, WithDataInterning())
+}
@@ -0,0 +1,51 @@
+package gointervaltree
+
+import (
+	"log"
+	"sync"
+)
+
+// TreePool struct defines a pool of reusable interval trees backed by sync.Pool reducing allocations when trees
+// are built and discarded at high rate. The zero value is ready to use.
+type TreePool struct {
+	pool sync.Pool
+}
+
+// Get method returns an empty tree over [min, max), either recycled from the pool or newly created.
+// Recycled trees are fully reset, including options and observers.
+func (p *TreePool) Get(min int, max int) *IntervalTree {
+	if !(min < max) {
+		log.Panic("AssertionError: interval tree start must be numerically less than its end")
+	}
+	tree, ok := p.pool.Get().(*IntervalTree)
+	if !ok {
+		return NewIntervalTree(min, max)
+	}
+	tree.clear()
+	tree.min = min
+	tree.max = max
+	tree.center = min + (max-min)/2
+	return tree
+}
+
+// Put method clears the tree and returns it to the pool, the tree must not be used afterwards.
+func (p *TreePool) Put(tree *IntervalTree) {
+	if tree == nil {
+		return
+	}
+	tree.clear()
+	p.pool.Put(tree)
+}
+
+// clear method is a technical method dropping all intervals, subtrees and settings of a root node.
+func (tree *IntervalTree) clear() {
+	tree.reset()
+	tree.observer = nil
+	tree.recentInserts = nil
+	tree.config = new(treeConfig)
+	tree.insertsSinceCheck = 0
+	tree.lenAtLastCheck = 0
+	tree.rebalances = 0
+	tree.insertions = 0
+	tree.registry = nil
+}
@@ -0,0 +1,37 @@
+package gointervaltree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreePool(t *testing.T) {
+	assert := assert.New(t)
+	var pool TreePool
+	tree := pool.Get(0, 100)
+	tree.AddInterval(10, 20, "stale")
+	tree.AddInterval(40, 60, "stale")
+	tree.Sort()
+	pool.Put(tree)
+	assert.Equal(0, tree.Len())
+	recycled := pool.Get(-50, 50)
+	assert.Equal(0, recycled.Len())
+	assert.Empty(recycled.Query(15))
+	assert.Empty(recycled.Iter())
+	assert.False(recycled.Undo())
+	assert.Equal(-50, recycled.min)
+	assert.Equal(50, recycled.max)
+	assert.Equal(0, recycled.center)
+	recycled.AddInterval(-10, 10, "fresh")
+	recycled.AddInterval(-40, -30, "fresh")
+	recycled.Sort()
+	assert.Equal([]interface{}{[]interface{}{-10, 10, "fresh"}}, recycled.Query(0))
+	assert.Equal(1, len(recycled.Query(-35)))
+	assert.Equal(2, recycled.Len())
+	pool.Put(nil)
+	assert.Panics(func() {
+		pool.Put(recycled)
+		pool.Get(10, 10)
+	})
+}
@@ -0,0 +1,599 @@
+package gointervaltree
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// queryIntervals method is a technical method returning all intervals which overlap given point as Interval values.
+func (tree *IntervalTree) queryIntervals(x int) []Interval {
+	var result []Interval
+	for _, r := range tree.query(x) {
+		result = append(result, r.Interval)
+	}
+	return result
+}
+
+// QueryPage method returns the [offset, offset+limit) slice of intervals overlapping given point, sorted by start
+// then by end for stability, along with the total number of overlapping intervals. Out-of-range offsets or
+// a non-positive limit yield an empty page.
+func (tree *IntervalTree) QueryPage(x int, offset int, limit int) (page []Interval, total int) {
+	matches := tree.queryIntervals(x)
+	total = len(matches)
+	if offset < 0 || offset >= total || limit <= 0 {
+		return []Interval{}, total
+	}
+	sortIntervals(matches)
+	end := offset + limit
+	if end > total || end < offset {
+		end = total
+	}
+	return matches[offset:end], total
+}
+
+// QuerySorted method returns all intervals which overlap given point sorted by start, then by end.
+// Query itself keeps the traversal order for performance.
+func (tree *IntervalTree) QuerySorted(x int) []Interval {
+	result := tree.queryIntervals(x)
+	sortIntervals(result)
+	return result
+}
+
+// QueryNotOverlapping method returns all intervals in the tree which do not overlap given point, i.e. all
+// intervals for which !(start <= x < end). Subtrees lying entirely on one side of x are taken over without
+// checking their intervals, so only the nodes on the lookup path of x are inspected.
+func (tree *IntervalTree) QueryNotOverlapping(x int) []Interval {
+	var result []Interval
+	for _, r := range tree.queryNotOverlapping(x) {
+		result = append(result, r.Interval)
+	}
+	return result
+}
+
+// queryNotOverlapping method is a technical method used inside QueryNotOverlapping for recursive lookup.
+func (tree *IntervalTree) queryNotOverlapping(x int) []*record {
+	var result []*record
+	if !tree.split {
+		if tree.singleInterval != nil && !tree.singleInterval.tombstone &&
+			!tree.config.contains(tree.singleInterval.Interval, x) {
+			result = append(result, tree.singleInterval)
+		}
+		return result
+	}
+	for _, element := range tree.overflow {
+		if !element.tombstone && !tree.config.contains(element.Interval, x) {
+			result = append(result, element)
+		}
+	}
+	if x < tree.center {
+		for i := len(tree.midSortedByStart) - 1; i >= 0; i-- {
+			element := tree.midSortedByStart[i]
+			if tree.config.lower(element.Start) <= x {
+				break
+			} else if !element.tombstone {
+				result = append(result, element)
+			}
+		}
+		if tree.leftSubtree != nil {
+			result = append(result, tree.leftSubtree.queryNotOverlapping(x)...)
+		}
+		if tree.rightSubtree != nil {
+			result = append(result, tree.rightSubtree.records()...)
+		}
+	} else {
+		for i := len(tree.midSortedByEnd) - 1; i >= 0; i-- {
+			element := tree.midSortedByEnd[i]
+			if tree.config.upper(element.End) > x {
+				break
+			} else if !element.tombstone {
+				result = append(result, element)
+			}
+		}
+		if tree.leftSubtree != nil {
+			result = append(result, tree.leftSubtree.records()...)
+		}
+		if tree.rightSubtree != nil {
+			result = append(result, tree.rightSubtree.queryNotOverlapping(x)...)
+		}
+	}
+	return result
+}
+
+// QuerySortedFunc method calls visit for every interval overlapping given point in the order of their starts,
+// stopping as soon as visit returns false. The sorted contributions of the nodes on the lookup path are merged
+// on the fly, so no overall result slice is built, only the contributions taken from midSortedByEnd, single and
+// overflow intervals are sorted by start separately.
+func (tree *IntervalTree) QuerySortedFunc(x int, visit func(Interval) bool) {
+	var runs recordRuns
+	for node := tree; node != nil; {
+		var run []*record
+		if !node.split {
+			if node.singleInterval != nil && !node.singleInterval.tombstone &&
+				node.config.contains(node.singleInterval.Interval, x) {
+				run = []*record{node.singleInterval}
+			}
+			node = nil
+		} else {
+			for _, r := range node.overflow {
+				if !r.tombstone && node.config.contains(r.Interval, x) {
+					run = append(run, r)
+				}
+			}
+			if x < node.center {
+				k := 0
+				for k < len(node.midSortedByStart) && node.config.lower(node.midSortedByStart[k].Start) <= x {
+					k++
+				}
+				if len(run) > 0 {
+					runs = append(runs, sortedByStart(run))
+				}
+				run = node.midSortedByStart[:k]
+				node = node.leftSubtree
+			} else {
+				for _, r := range node.midSortedByEnd {
+					if node.config.upper(r.End) <= x {
+						break
+					}
+					run = append(run, r)
+				}
+				run = sortedByStart(run)
+				node = node.rightSubtree
+			}
+		}
+		if len(run) > 0 {
+			runs = append(runs, run)
+		}
+	}
+	heap.Init(&runs)
+	for len(runs) > 0 {
+		r := runs[0][0]
+		if runs[0] = runs[0][1:]; len(runs[0]) == 0 {
+			heap.Pop(&runs)
+		} else {
+			heap.Fix(&runs, 0)
+		}
+		if !r.tombstone && !visit(r.Interval) {
+			return
+		}
+	}
+}
+
+// sortedByStart function sorts records by start, then by end, and returns them.
+func sortedByStart(records []*record) []*record {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Start != records[j].Start {
+			return records[i].Start < records[j].Start
+		}
+		return records[i].End < records[j].End
+	})
+	return records
+}
+
+// recordRuns type implements heap.Interface over non-empty runs of records sorted by start, ordered by the start
+// of their first record, for the k-way merge inside QuerySortedFunc.
+type recordRuns [][]*record
+
+func (h recordRuns) Len() int { return len(h) }
+func (h recordRuns) Less(i, j int) bool {
+	if h[i][0].Start != h[j][0].Start {
+		return h[i][0].Start < h[j][0].Start
+	}
+	return h[i][0].End < h[j][0].End
+}
+func (h recordRuns) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *recordRuns) Push(x interface{}) { *h = append(*h, x.([]*record)) }
+func (h *recordRuns) Pop() interface{} {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return run
+}
+
+// RankedInterval struct defines an interval along with its zero-based position in IterSorted.
+type RankedInterval struct {
+	Interval
+	Rank int
+}
+
+// QueryWithRank method returns all intervals which overlap given point, in the order of Query, each tagged with
+// its position among all intervals of the tree sorted as by IterSorted. Ranking sorts the whole tree, so
+// the call costs O(n log n) rather than the cost of a query.
+func (tree *IntervalTree) QueryWithRank(x int) []RankedInterval {
+	var result []RankedInterval
+	matched := tree.query(x)
+	if len(matched) == 0 {
+		return result
+	}
+	records := tree.records()
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Start != records[j].Start {
+			return records[i].Start < records[j].Start
+		}
+		return records[i].End < records[j].End
+	})
+	ranks := make(map[*record]int, len(records))
+	for i, r := range records {
+		ranks[r] = i
+	}
+	for _, r := range matched {
+		result = append(result, RankedInterval{Interval: r.Interval, Rank: ranks[r]})
+	}
+	return result
+}
+
+// QuerySortedBy method returns all intervals which overlap given point sorted by the given less function,
+// intervals equal according to less keep the order of Query.
+func (tree *IntervalTree) QuerySortedBy(x int, less func(a Interval, b Interval) bool) []Interval {
+	result := tree.queryIntervals(x)
+	sort.SliceStable(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+	return result
+}
+
+// BestOverlap method returns the interval with the largest overlap with range [low, high) along with its
+// overlap length, ties being broken by the smallest start. False is returned if nothing overlaps the range.
+func (tree *IntervalTree) BestOverlap(low int, high int) (Interval, int, bool) {
+	var best Interval
+	bestLength := 0
+	found := false
+	for _, interval := range tree.QueryRange(low, high) {
+		length := overlapLength(interval, low, high)
+		if !found || length > bestLength || (length == bestLength && interval.Start < best.Start) {
+			best, bestLength, found = interval, length, true
+		}
+	}
+	return best, bestLength, found
+}
+
+// QueryPoints method returns the intervals overlapping each of the given points keyed by point, points without
+// overlapping intervals map to an empty slice. Note that QueryMulti is taken by the AddMulti counterpart.
+func (tree *IntervalTree) QueryPoints(points []int) map[int][]Interval {
+	result := make(map[int][]Interval, len(points))
+	for _, x := range points {
+		if _, ok := result[x]; !ok {
+			result[x] = append([]Interval{}, tree.queryIntervals(x)...)
+		}
+	}
+	return result
+}
+
+// ExistsInRange method reports whether any interval in the tree overlaps given range [low, high),
+// returning as soon as the first overlapping interval is found.
+func (tree *IntervalTree) ExistsInRange(low int, high int) bool {
+	if (high - low) <= 0 {
+		return false
+	}
+	if !tree.split {
+		return tree.singleInterval != nil && !tree.singleInterval.tombstone &&
+			tree.config.overlaps(tree.singleInterval.Interval, low, high)
+	}
+	for _, element := range tree.overflow {
+		if !element.tombstone && tree.config.overlaps(element.Interval, low, high) {
+			return true
+		}
+	}
+	if high <= tree.center {
+		for _, element := range tree.midSortedByStart {
+			if tree.config.lower(element.Start) >= high {
+				break
+			} else if !element.tombstone {
+				return true
+			}
+		}
+	} else if low > tree.center {
+		for _, element := range tree.midSortedByEnd {
+			if tree.config.upper(element.End) <= low {
+				break
+			} else if !element.tombstone {
+				return true
+			}
+		}
+	} else if len(tree.midSortedByStart) > tree.tombstones {
+		return true
+	}
+	if low <= tree.center && tree.leftSubtree != nil && tree.leftSubtree.ExistsInRange(low, high) {
+		return true
+	}
+	return high > tree.center && tree.rightSubtree != nil && tree.rightSubtree.ExistsInRange(low, high)
+}
+
+// CountEnclosing method returns the number of intervals in the tree covering every point of given range
+// [low, high), i.e. for which (start <= low && high <= end), without collecting them. Such intervals overlap low,
+// so only the lookup path of low is visited. An empty range yields 0.
+func (tree *IntervalTree) CountEnclosing(low int, high int) int {
+	if (high - low) <= 0 {
+		return 0
+	}
+	encloses := func(r *record) bool {
+		return !r.tombstone && tree.config.lower(r.Start) <= low && high <= tree.config.upper(r.End)
+	}
+	count := 0
+	for node := tree; node != nil; {
+		if !node.split {
+			if node.singleInterval != nil && encloses(node.singleInterval) {
+				count++
+			}
+			break
+		}
+		for _, r := range node.overflow {
+			if encloses(r) {
+				count++
+			}
+		}
+		if low < node.center {
+			for _, r := range node.midSortedByStart {
+				if node.config.lower(r.Start) > low {
+					break
+				} else if encloses(r) {
+					count++
+				}
+			}
+			node = node.leftSubtree
+		} else {
+			for _, r := range node.midSortedByEnd {
+				if node.config.upper(r.End) < high {
+					break
+				} else if encloses(r) {
+					count++
+				}
+			}
+			node = node.rightSubtree
+		}
+	}
+	return count
+}
+
+// WriteOverlapping method writes every interval overlapping given point to w as a "[start, end) data" line, data
+// being formatted with %v, in the order of Query. It returns the number of bytes written and stops at the first
+// write error.
+func (tree *IntervalTree) WriteOverlapping(w io.Writer, x int) (int, error) {
+	written := 0
+	for _, r := range tree.query(x) {
+		n, err := fmt.Fprintf(w, "[%d, %d) %v\n", r.Start, r.End, r.Data)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// EnclosersOf method returns all intervals in the tree covering every point of the query interval q, i.e. for
+// which (start <= q.Start && q.End <= end) in the default mode, in the order of Query. It collects the intervals
+// counted by CountEnclosing, the points of q are those covered according to the interval mode and an interval q
+// covering no point yields nothing.
+func (tree *IntervalTree) EnclosersOf(q Interval) []Interval {
+	var result []Interval
+	low, high := tree.config.lower(q.Start), tree.config.upper(q.End)
+	if high <= low {
+		return result
+	}
+	for _, r := range tree.queryWhere(low, func(r *record) bool { return high <= tree.config.upper(r.End) }) {
+		result = append(result, r.Interval)
+	}
+	return result
+}
+
+// IsRangeFree method reports whether no interval in the tree overlaps given range [low, high), i.e. it is
+// the negation of ExistsInRange and returns as soon as the first overlapping interval is found.
+func (tree *IntervalTree) IsRangeFree(low int, high int) bool {
+	return !tree.ExistsInRange(low, high)
+}
+
+// OverlapsAny method reports for each of the given intervals whether it overlaps any interval in the tree.
+func (tree *IntervalTree) OverlapsAny(intervals []Interval) []bool {
+	return tree.ExistsInRanges(intervals)
+}
+
+// ExistsInRanges method reports for each of the given ranges [start, end) whether any interval in the tree
+// overlaps it, i.e. the result holds ExistsInRange of every range.
+func (tree *IntervalTree) ExistsInRanges(ranges []Interval) []bool {
+	result := make([]bool, len(ranges))
+	for i, r := range ranges {
+		result[i] = tree.ExistsInRange(r.Start, r.End)
+	}
+	return result
+}
+
+// LongestAt method returns the data of the longest interval overlapping given point, ties being broken by
+// the smallest start. False is returned if no interval overlaps the point.
+func (tree *IntervalTree) LongestAt(x int) (interface{}, bool) {
+	var longest Interval
+	found := false
+	for _, interval := range tree.queryIntervals(x) {
+		length, longestLength := interval.End-interval.Start, longest.End-longest.Start
+		if !found || length > longestLength || (length == longestLength && interval.Start < longest.Start) {
+			longest, found = interval, true
+		}
+	}
+	return longest.Data, found
+}
+
+// ContainsInterval method reports whether an interval with exactly the given coordinates and data, compared via
+// reflect.DeepEqual, is maintained in the tree. Only the node the interval would be placed into is searched.
+func (tree *IntervalTree) ContainsInterval(start int, end int, data interface{}) bool {
+	return tree.findRecord(start, end, dataEquals(data)) != nil
+}
+
+// TopPriorityAt method returns the interval overlapping given point whose data has the highest priority as
+// computed by the priority function, ties being broken by the smallest start. False is returned if no interval
+// overlaps the point.
+func (tree *IntervalTree) TopPriorityAt(x int, priority func(data interface{}) int) (Interval, bool) {
+	var top Interval
+	var topPriority int
+	found := false
+	for _, interval := range tree.queryIntervals(x) {
+		p := priority(interval.Data)
+		if !found || p > topPriority || (p == topPriority && interval.Start < top.Start) {
+			top, topPriority, found = interval, p, true
+		}
+	}
+	return top, found
+}
+
+// QueryWithTolerance method returns all intervals in the tree which overlap given point widened by tol on both
+// sides, i.e. all intervals for which (start - tol <= x < end + tol). This equals QueryRange(x-tol, x+tol+1),
+// a negative tolerance is treated as zero.
+func (tree *IntervalTree) QueryWithTolerance(x int, tol int) []Interval {
+	if tol < 0 {
+		tol = 0
+	}
+	return tree.QueryRange(x-tol, x+tol+1)
+}
+
+// QueryCategory method returns all intervals overlapping given point whose data belongs to the given category
+// as computed by key, in the order of Query. The category is checked during the lookup.
+func (tree *IntervalTree) QueryCategory(x int, category string, key func(data interface{}) string) []Interval {
+	var result []Interval
+	for _, r := range tree.queryWhere(x, func(r *record) bool { return key(r.Data) == category }) {
+		result = append(result, r.Interval)
+	}
+	return result
+}
+
+// Relation type defines how an interval overlapping a query interval relates to it.
+type Relation int
+
+const (
+	// Equal relation means the interval covers exactly the points of the query interval.
+	Equal Relation = iota
+	// QueryContains relation means the query interval covers all points of the interval and more.
+	QueryContains
+	// ContainsQuery relation means the interval covers all points of the query interval and more.
+	ContainsQuery
+	// PartialOverlap relation means that each of the intervals covers points the other one does not.
+	PartialOverlap
+)
+
+// IntervalRelation struct defines an interval matched by QueryRelations along with its Relation to the query.
+type IntervalRelation struct {
+	Interval
+	Relation Relation
+}
+
+// QueryRelations method returns all intervals overlapping the query interval q, in the order of QueryRange, each
+// labelled with its Relation to q. Relations compare the points covered according to the interval mode.
+func (tree *IntervalTree) QueryRelations(q Interval) []IntervalRelation {
+	var result []IntervalRelation
+	low, high := tree.config.lower(q.Start), tree.config.upper(q.End)
+	if high <= low {
+		return result
+	}
+	for _, r := range tree.queryRange(low, high) {
+		lower, upper := tree.config.lower(r.Start), tree.config.upper(r.End)
+		relation := PartialOverlap
+		if lower == low && upper == high {
+			relation = Equal
+		} else if low <= lower && upper <= high {
+			relation = QueryContains
+		} else if lower <= low && high <= upper {
+			relation = ContainsQuery
+		}
+		result = append(result, IntervalRelation{Interval: r.Interval, Relation: relation})
+	}
+	return result
+}
+
+// Touching method returns all intervals in the tree sharing an endpoint with the interval iv without
+// overlapping it, i.e. for which (end == iv.Start || start == iv.End) under half-open semantics regardless of
+// the interval mode, in the order of QueryRange.
+func (tree *IntervalTree) Touching(iv Interval) []Interval {
+	var result []Interval
+	// the lookup range is widened by a point on the right so that neighbours starting at iv.End are found
+	// in Open mode as well, where they cover no point before iv.End+1
+	for _, r := range tree.queryRange(iv.Start-1, iv.End+2) {
+		if (r.End == iv.Start || r.Start == iv.End) && !(r.Start < iv.End && iv.Start < r.End) {
+			result = append(result, r.Interval)
+		}
+	}
+	return result
+}
+
+// QueryChanContext method returns a channel streaming the intervals overlapping given point, in the order of
+// Query. The intervals are looked up before this method returns, so later changes of the tree do not affect them,
+// and are sent by a goroutine which closes the channel once all of them are sent or ctx is cancelled. Cancelling
+// ctx is required to release the goroutine when the channel is not drained.
+func (tree *IntervalTree) QueryChanContext(ctx context.Context, x int) <-chan Interval {
+	records := tree.query(x)
+	result := make(chan Interval)
+	go func() {
+		defer close(result)
+		for _, r := range records {
+			select {
+			case result <- r.Interval:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result
+}
+
+// NextEndingAfter method returns the interval with the smallest end such that (end > x), ties broken by the
+// smallest start, false is returned if no interval ends after x. The ends of the left subtree of a node precede
+// those of its mid intervals and of its right subtree, so the latter are only searched when the former yields
+// nothing.
+func (tree *IntervalTree) NextEndingAfter(x int) (Interval, bool) {
+	if r := tree.nextEndingAfter(x); r != nil {
+		return r.Interval, true
+	}
+	return Interval{}, false
+}
+
+// nextEndingAfter method is a technical method used inside NextEndingAfter for recursive lookup.
+func (tree *IntervalTree) nextEndingAfter(x int) *record {
+	var best *record
+	consider := func(r *record) {
+		if !r.tombstone && r.End > x &&
+			(best == nil || r.End < best.End || (r.End == best.End && r.Start < best.Start)) {
+			best = r
+		}
+	}
+	if !tree.split {
+		if tree.singleInterval != nil {
+			consider(tree.singleInterval)
+		}
+		return best
+	}
+	for _, element := range tree.overflow {
+		consider(element)
+	}
+	if tree.leftSubtree != nil && x < tree.center-tree.config.endShift {
+		if r := tree.leftSubtree.nextEndingAfter(x); r != nil {
+			consider(r)
+			return best
+		}
+	}
+	for i := len(tree.midSortedByEnd) - 1; i >= 0; i-- {
+		element := tree.midSortedByEnd[i]
+		if best != nil && element.End > best.End {
+			break
+		}
+		consider(element)
+	}
+	if tree.rightSubtree != nil {
+		if r := tree.rightSubtree.nextEndingAfter(x); r != nil {
+			consider(r)
+		}
+	}
+	return best
+}
+
+// QueryBounds method returns the smallest start and the largest end of the intervals overlapping given point,
+// i.e. the tightest range enclosing all intervals returned by Query, false is returned if none overlaps x.
+func (tree *IntervalTree) QueryBounds(x int) (start int, end int, ok bool) {
+	for _, r := range tree.query(x) {
+		if !ok || r.Start < start {
+			start = r.Start
+		}
+		if !ok || r.End > end {
+			end = r.End
+		}
+		ok = true
+	}
+	return start, end, ok
+}
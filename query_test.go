@@ -0,0 +1,633 @@
+package gointervaltree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryPage(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000)
+	for i := 0; i < 250; i++ {
+		tree.AddInterval(i, 500+i%7, i)
+	}
+	tree.AddInterval(600, 700, "outside")
+	tree.Sort()
+	var pages []Interval
+	for offset := 0; offset < 300; offset += 40 {
+		page, total := tree.QueryPage(300, offset, 40)
+		assert.Equal(250, total)
+		pages = append(pages, page...)
+	}
+	assert.Equal(250, len(pages))
+	seen := make(map[interface{}]bool)
+	for i, interval := range pages {
+		assert.False(seen[interval.Data])
+		seen[interval.Data] = true
+		assert.Equal(i, interval.Start)
+	}
+	page, total := tree.QueryPage(300, 250, 10)
+	assert.Empty(page)
+	assert.Equal(250, total)
+	page, total = tree.QueryPage(300, -1, 10)
+	assert.Empty(page)
+	assert.Equal(250, total)
+	page, total = tree.QueryPage(650, 0, 10)
+	assert.Equal([]Interval{{600, 700, "outside"}}, page)
+	assert.Equal(1, total)
+	page, total = tree.QueryPage(900, 0, 10)
+	assert.Empty(page)
+	assert.Equal(0, total)
+}
+
+func TestQuerySorted(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range [][]int{{45, 55}, {10, 60}, {40, 90}, {48, 52}, {10, 50}, {50, 51}, {1, 99}, {70, 80}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	result := tree.QuerySorted(49)
+	assert.Equal([]Interval{{1, 99, nil}, {10, 50, nil}, {10, 60, nil}, {40, 90, nil}, {45, 55, nil}, {48, 52, nil}},
+		result)
+	var unsorted []Interval
+	for _, element := range tree.Query(49) {
+		r := element.([]interface{})
+		unsorted = append(unsorted, Interval{r[0].(int), r[1].(int), r[2]})
+	}
+	assert.ElementsMatch(unsorted, result)
+	assert.Empty(tree.QuerySorted(99))
+}
+
+func TestQueryNotOverlapping(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000)
+	for i := 0; i < 300; i++ {
+		tree.AddInterval((i*37)%900, (i*37)%900+1+(i*13)%120, i)
+	}
+	tree.SoftRemove(37, 51, 1)
+	tree.Sort()
+	for q := -5; q < 1005; q += 3 {
+		overlapping := tree.queryIntervals(q)
+		var expected []Interval
+		for _, interval := range tree.intervals() {
+			if !(interval.Start <= q && q < interval.End) {
+				expected = append(expected, interval)
+			}
+		}
+		assert.Equal(tree.Len()-len(overlapping), len(expected))
+		assert.ElementsMatch(expected, tree.QueryNotOverlapping(q))
+	}
+}
+
+func TestQuerySortedFunc(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000)
+	for i := 0; i < 400; i++ {
+		tree.AddInterval((i*37)%900, (i*37)%900+1+(i*13)%120, i)
+	}
+	tree.SoftRemove(37, 51, 1)
+	tree.Sort()
+	for q := 0; q < 1000; q += 7 {
+		var visited []Interval
+		tree.QuerySortedFunc(q, func(interval Interval) bool {
+			visited = append(visited, interval)
+			return true
+		})
+		assert.ElementsMatch(tree.queryIntervals(q), visited)
+		for i := 1; i < len(visited); i++ {
+			assert.LessOrEqual(visited[i-1].Start, visited[i].Start)
+		}
+	}
+	expected := tree.QuerySorted(500)
+	assert.Greater(len(expected), 3)
+	var visited []Interval
+	tree.QuerySortedFunc(500, func(interval Interval) bool {
+		visited = append(visited, interval)
+		return len(visited) < 3
+	})
+	assert.Equal(3, len(visited))
+	for i := range visited {
+		assert.Equal(expected[i].Start, visited[i].Start)
+	}
+	calls := 0
+	tree.QuerySortedFunc(999, func(Interval) bool {
+		calls++
+		return true
+	})
+	assert.Equal(len(tree.Query(999)), calls)
+}
+
+func TestQueryWithRank(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for i, interval := range [][]int{{40, 60}, {10, 20}, {45, 50}, {0, 100}, {45, 50}, {70, 80}, {15, 48}} {
+		tree.AddInterval(interval[0], interval[1], i)
+	}
+	tree.Sort()
+	assert.Empty(NewIntervalTree(0, 10).QueryWithRank(5))
+	sorted := tree.IterSorted()
+	for q := 0; q < 100; q++ {
+		ranked := tree.QueryWithRank(q)
+		assert.Equal(len(tree.Query(q)), len(ranked))
+		for _, interval := range ranked {
+			assert.Equal(sorted[interval.Rank], interval.Interval)
+		}
+	}
+	var ranks []int
+	for _, interval := range tree.QueryWithRank(47) {
+		ranks = append(ranks, interval.Rank)
+	}
+	assert.ElementsMatch([]int{0, 2, 3, 4, 5}, ranks)
+}
+
+func TestQuerySortedBy(t *testing.T) {
+	assert := assert.New(t)
+	type person struct {
+		name string
+	}
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 60, person{"mallory"})
+	tree.AddInterval(40, 60, person{"alice"})
+	tree.AddInterval(45, 50, person{"carol"})
+	tree.AddInterval(30, 90, person{"bob"})
+	tree.AddInterval(70, 80, person{"dave"})
+	tree.Sort()
+	byName := func(a Interval, b Interval) bool {
+		return a.Data.(person).name < b.Data.(person).name
+	}
+	var names []string
+	for _, interval := range tree.QuerySortedBy(47, byName) {
+		names = append(names, interval.Data.(person).name)
+	}
+	assert.Equal([]string{"alice", "bob", "carol", "mallory"}, names)
+	assert.Empty(tree.QuerySortedBy(5, byName))
+}
+
+func TestBestOverlap(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 25, "a")
+	tree.AddInterval(22, 28, "b")
+	tree.AddInterval(25, 45, "c")
+	tree.AddInterval(15, 32, "d")
+	tree.AddInterval(60, 70, "e")
+	tree.Sort()
+	best, length, ok := tree.BestOverlap(20, 40)
+	assert.True(ok)
+	assert.Equal(Interval{25, 45, "c"}, best)
+	assert.Equal(15, length)
+	best, length, ok = tree.BestOverlap(20, 30)
+	assert.True(ok)
+	assert.Equal(Interval{15, 32, "d"}, best)
+	assert.Equal(10, length)
+	best, length, ok = tree.BestOverlap(23, 25)
+	assert.True(ok)
+	assert.Equal(Interval{10, 25, "a"}, best)
+	assert.Equal(2, length)
+	_, _, ok = tree.BestOverlap(48, 58)
+	assert.False(ok)
+}
+
+func TestQueryPoints(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range [][]int{{10, 20}, {15, 60}, {40, 55}, {70, 80}, {0, 100}} {
+		tree.AddInterval(interval[0], interval[1], interval)
+	}
+	tree.Sort()
+	points := []int{5, 15, 15, 50, 75, 99, 100}
+	result := tree.QueryPoints(points)
+	assert.Equal(6, len(result))
+	for _, x := range points {
+		assert.ElementsMatch(tree.queryIntervals(x), result[x])
+		assert.Equal(len(tree.Query(x)), len(result[x]))
+	}
+	assert.NotNil(result[100])
+	assert.Empty(result[100])
+}
+
+func TestExistsInRange(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	intervals := [][]int{{10, 20}, {15, 60}, {40, 55}, {70, 80}, {50, 51}, {90, 91}}
+	for _, interval := range intervals {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	for low := -2; low < 102; low++ {
+		for high := low; high < 104; high += 3 {
+			assert.Equal(len(tree.QueryRange(low, high)) > 0, tree.ExistsInRange(low, high), "%d %d", low, high)
+		}
+	}
+}
+
+func TestCountEnclosing(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1000)
+	for i := 0; i < 300; i++ {
+		tree.AddInterval((i*37)%900, (i*37)%900+1+(i*13)%200, i)
+	}
+	tree.AddInterval(0, 1000, "whole")
+	tree.SoftRemove(37, 51, 1)
+	tree.Sort()
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		low := random.Intn(1000)
+		high := low + random.Intn(150)
+		expected := 0
+		for _, interval := range tree.intervals() {
+			if interval.Start <= low && high <= interval.End {
+				expected++
+			}
+		}
+		if low == high {
+			expected = 0
+		}
+		assert.Equal(expected, tree.CountEnclosing(low, high))
+	}
+	assert.Equal(1, tree.CountEnclosing(0, 1000))
+}
+
+func TestEnclosersOf(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(0, 100, "whole")
+	tree.AddInterval(20, 50, "exact")
+	tree.AddInterval(10, 60, "wider")
+	tree.AddInterval(25, 60, "late start")
+	tree.AddInterval(10, 45, "early end")
+	tree.AddInterval(70, 90, "disjoint")
+	tree.Sort()
+	assert.ElementsMatch([]Interval{{0, 100, "whole"}, {20, 50, "exact"}, {10, 60, "wider"}},
+		tree.EnclosersOf(Interval{Start: 20, End: 50}))
+	assert.Equal(tree.CountEnclosing(20, 50), len(tree.EnclosersOf(Interval{Start: 20, End: 50})))
+	assert.ElementsMatch([]Interval{{0, 100, "whole"}, {70, 90, "disjoint"}},
+		tree.EnclosersOf(Interval{Start: 75, End: 80}))
+	assert.Empty(tree.EnclosersOf(Interval{Start: 30, End: 30}))
+	tree.SoftRemove(0, 100, "whole")
+	assert.Empty(tree.EnclosersOf(Interval{Start: 5, End: 95}))
+}
+
+func TestWriteOverlapping(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 60, "a")
+	tree.AddInterval(40, 60, []int{1, 2})
+	tree.AddInterval(45, 50, nil)
+	tree.AddInterval(70, 80, "far")
+	tree.Sort()
+	var buffer bytes.Buffer
+	n, err := tree.WriteOverlapping(&buffer, 47)
+	assert.NoError(err)
+	var expected strings.Builder
+	for _, match := range tree.Query(47) {
+		fields := match.([]interface{})
+		expected.WriteString(fmt.Sprintf("[%d, %d) %v\n", fields[0], fields[1], fields[2]))
+	}
+	assert.Equal(expected.String(), buffer.String())
+	assert.Equal(buffer.Len(), n)
+	assert.Contains(buffer.String(), "[40, 60) [1 2]\n")
+	buffer.Reset()
+	n, err = tree.WriteOverlapping(&buffer, 5)
+	assert.NoError(err)
+	assert.Equal(0, n)
+}
+
+func TestIsRangeFree(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.True(tree.IsRangeFree(0, 100))
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(40, 60, nil)
+	tree.Sort()
+	assert.True(tree.IsRangeFree(0, 10))
+	assert.True(tree.IsRangeFree(20, 40))
+	assert.True(tree.IsRangeFree(60, 100))
+	assert.False(tree.IsRangeFree(19, 21))
+	assert.False(tree.IsRangeFree(30, 41))
+	assert.False(tree.IsRangeFree(0, 100))
+}
+
+func TestOverlapsAny(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(40, 60, nil)
+	tree.Sort()
+	inputs := []Interval{{0, 10, nil}, {5, 11, nil}, {20, 40, nil}, {55, 70, nil}, {60, 99, nil}, {45, 46, nil}}
+	assert.Equal([]bool{false, true, false, true, false, true}, tree.OverlapsAny(inputs))
+	assert.Empty(tree.OverlapsAny(nil))
+}
+
+func TestExistsInRanges(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Equal([]bool{false}, tree.ExistsInRanges([]Interval{{0, 100, nil}}))
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(40, 60, nil)
+	tree.AddInterval(0, 1, nil)
+	tree.Sort()
+	ranges := []Interval{{-10, 0, nil}, {-10, 1, nil}, {5, 11, nil}, {20, 40, nil}, {59, 200, nil}, {60, 200, nil},
+		{50, 50, nil}, {45, 46, nil}}
+	expected := make([]bool, len(ranges))
+	for i, r := range ranges {
+		expected[i] = tree.ExistsInRange(r.Start, r.End)
+	}
+	assert.Equal([]bool{false, true, true, false, true, false, false, true}, expected)
+	assert.Equal(expected, tree.ExistsInRanges(ranges))
+	shifted := NewIntervalTree(0, 100, WithIntervalMode(OpenClosed))
+	shifted.AddInterval(-1, 5, "edge left")
+	shifted.AddInterval(90, 99, "edge right")
+	shifted.Sort()
+	ranges = []Interval{{-5, 0, nil}, {-5, 1, nil}, {99, 150, nil}, {100, 150, nil}, {-50, 200, nil}, {200, 300, nil}}
+	expected = make([]bool, len(ranges))
+	for i, r := range ranges {
+		expected[i] = shifted.ExistsInRange(r.Start, r.End)
+	}
+	assert.Equal([]bool{false, true, true, false, true, false}, expected)
+	assert.Equal(expected, shifted.ExistsInRanges(ranges))
+}
+
+func TestLongestAt(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(45, 55, "narrow")
+	tree.AddInterval(30, 70, "broad")
+	tree.AddInterval(40, 60, "middle")
+	tree.AddInterval(70, 80, "right")
+	tree.Sort()
+	data, ok := tree.LongestAt(50)
+	assert.True(ok)
+	assert.Equal("broad", data)
+	data, ok = tree.LongestAt(75)
+	assert.True(ok)
+	assert.Equal("right", data)
+	_, ok = tree.LongestAt(90)
+	assert.False(ok)
+}
+
+func TestContainsInterval(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, "left")
+	tree.AddInterval(40, 60, []int{1, 2})
+	tree.AddInterval(70, 80, nil)
+	tree.Sort()
+	assert.True(tree.ContainsInterval(10, 20, "left"))
+	assert.True(tree.ContainsInterval(40, 60, []int{1, 2}))
+	assert.True(tree.ContainsInterval(70, 80, nil))
+	assert.False(tree.ContainsInterval(10, 20, "right"))
+	assert.False(tree.ContainsInterval(40, 60, []int{1}))
+	assert.False(tree.ContainsInterval(10, 21, "left"))
+	assert.False(tree.ContainsInterval(90, 95, nil))
+	tree.SoftRemove(10, 20, "left")
+	assert.False(tree.ContainsInterval(10, 20, "left"))
+}
+
+func TestTopPriorityAt(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(30, 70, 1)
+	tree.AddInterval(45, 55, 5)
+	tree.AddInterval(40, 60, 5)
+	tree.AddInterval(48, 52, 3)
+	tree.AddInterval(80, 90, -2)
+	tree.Sort()
+	priority := func(data interface{}) int { return data.(int) }
+	top, ok := tree.TopPriorityAt(50, priority)
+	assert.True(ok)
+	assert.Equal(Interval{40, 60, 5}, top)
+	top, ok = tree.TopPriorityAt(35, priority)
+	assert.True(ok)
+	assert.Equal(Interval{30, 70, 1}, top)
+	top, ok = tree.TopPriorityAt(85, priority)
+	assert.True(ok)
+	assert.Equal(-2, top.Data)
+	_, ok = tree.TopPriorityAt(95, priority)
+	assert.False(ok)
+}
+
+func TestQueryWithTolerance(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 20, "a")
+	tree.AddInterval(40, 60, "b")
+	tree.AddInterval(63, 70, "c")
+	tree.Sort()
+	assert.ElementsMatch([]Interval{{40, 60, "b"}}, tree.QueryWithTolerance(50, 0))
+	assert.Empty(tree.QueryWithTolerance(60, 0))
+	assert.ElementsMatch([]Interval{{40, 60, "b"}}, tree.QueryWithTolerance(60, 1))
+	assert.ElementsMatch([]Interval{{40, 60, "b"}, {63, 70, "c"}}, tree.QueryWithTolerance(61, 2))
+	assert.Empty(tree.QueryWithTolerance(61, 1))
+	assert.ElementsMatch([]Interval{{10, 20, "a"}}, tree.QueryWithTolerance(7, 3))
+	assert.Empty(tree.QueryWithTolerance(6, 3))
+	assert.ElementsMatch([]Interval{{10, 20, "a"}}, tree.QueryWithTolerance(15, -5))
+}
+
+func TestQueryCategory(t *testing.T) {
+	assert := assert.New(t)
+	type event struct {
+		kind string
+		name string
+	}
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 60, event{"meeting", "standup"})
+	tree.AddInterval(40, 60, event{"holiday", "local"})
+	tree.AddInterval(45, 50, event{"meeting", "review"})
+	tree.AddInterval(70, 80, event{"meeting", "retro"})
+	tree.AddInterval(20, 90, nil)
+	tree.Sort()
+	key := func(data interface{}) string {
+		if e, ok := data.(event); ok {
+			return e.kind
+		}
+		return ""
+	}
+	assert.ElementsMatch([]Interval{{10, 60, event{"meeting", "standup"}}, {45, 50, event{"meeting", "review"}}},
+		tree.QueryCategory(47, "meeting", key))
+	assert.Equal([]Interval{{40, 60, event{"holiday", "local"}}}, tree.QueryCategory(47, "holiday", key))
+	assert.Equal([]Interval{{20, 90, nil}}, tree.QueryCategory(85, "", key))
+	assert.Empty(tree.QueryCategory(75, "holiday", key))
+}
+
+func TestQueryRelations(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(40, 60, "equal")
+	tree.AddInterval(45, 50, "inside")
+	tree.AddInterval(40, 50, "inside sharing start")
+	tree.AddInterval(30, 70, "around")
+	tree.AddInterval(40, 61, "around sharing start")
+	tree.AddInterval(30, 45, "partial left")
+	tree.AddInterval(55, 80, "partial right")
+	tree.AddInterval(60, 70, "touching")
+	tree.AddInterval(10, 20, "far")
+	tree.Sort()
+	relations := make(map[interface{}]Relation)
+	for _, match := range tree.QueryRelations(Interval{40, 60, nil}) {
+		relations[match.Data] = match.Relation
+	}
+	assert.Equal(map[interface{}]Relation{
+		"equal":                Equal,
+		"inside":               QueryContains,
+		"inside sharing start": QueryContains,
+		"around":               ContainsQuery,
+		"around sharing start": ContainsQuery,
+		"partial left":         PartialOverlap,
+		"partial right":        PartialOverlap,
+	}, relations)
+	assert.Empty(tree.QueryRelations(Interval{50, 50, nil}))
+	closed := NewIntervalTree(0, 100, WithIntervalMode(Closed))
+	closed.AddInterval(40, 60, "closed")
+	closed.Sort()
+	assert.Equal([]IntervalRelation{{Interval{40, 60, "closed"}, ContainsQuery}},
+		closed.QueryRelations(Interval{60, 60, nil}))
+}
+
+func TestTouching(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, "left")
+	tree.AddInterval(50, 70, "right")
+	tree.AddInterval(20, 40, "overlapping")
+	tree.AddInterval(30, 50, "same")
+	tree.AddInterval(0, 5, "disjoint")
+	tree.AddInterval(52, 60, "disjoint")
+	tree.Sort()
+	assert.ElementsMatch([]Interval{{10, 30, "left"}, {50, 70, "right"}}, tree.Touching(Interval{Start: 30, End: 50}))
+	assert.Equal([]Interval{{0, 5, "disjoint"}}, tree.Touching(Interval{Start: 5, End: 9}))
+	assert.Empty(tree.Touching(Interval{Start: 80, End: 90}))
+	open := NewIntervalTree(0, 100, WithIntervalMode(Open))
+	open.AddInterval(10, 30, "left")
+	open.AddInterval(50, 70, "right")
+	open.Sort()
+	assert.ElementsMatch([]Interval{{10, 30, "left"}, {50, 70, "right"}}, open.Touching(Interval{Start: 30, End: 50}))
+}
+
+func TestQueryChanContext(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for i := 0; i < 50; i++ {
+		tree.AddInterval(i, 50+i, i)
+	}
+	tree.Sort()
+	var streamed []Interval
+	for interval := range tree.QueryChanContext(context.Background(), 49) {
+		streamed = append(streamed, interval)
+	}
+	assert.Equal(tree.queryIntervals(49), streamed)
+	goroutines := runtime.NumGoroutine()
+	tree.QueryChanContext(context.Background(), 99)
+	assert.True(goroutinesSettle(goroutines))
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := tree.QueryChanContext(ctx, 49)
+	for i := 0; i < 5; i++ {
+		<-stream
+	}
+	cancel()
+	received := 5
+	for range stream {
+		received++
+	}
+	assert.Less(received, 50)
+	assert.True(goroutinesSettle(goroutines))
+	ctx, cancel = context.WithCancel(context.Background())
+	tree.QueryChanContext(ctx, 49)
+	cancel()
+	assert.True(goroutinesSettle(goroutines))
+}
+
+// goroutinesSettle function waits up to a second for the number of goroutines to drop to count.
+func goroutinesSettle(count int) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if runtime.NumGoroutine() <= count {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNextEndingAfter(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	_, ok := tree.NextEndingAfter(10)
+	assert.False(ok)
+	tree.AddInterval(0, 15, "a")
+	tree.AddInterval(10, 60, "b")
+	tree.AddInterval(40, 55, "c")
+	tree.AddInterval(45, 55, "d")
+	tree.AddInterval(70, 90, "e")
+	tree.AddInterval(5, 80, "f")
+	tree.Sort()
+	next, ok := tree.NextEndingAfter(0)
+	assert.True(ok)
+	assert.Equal(Interval{0, 15, "a"}, next)
+	next, _ = tree.NextEndingAfter(15)
+	assert.Equal(Interval{40, 55, "c"}, next)
+	next, _ = tree.NextEndingAfter(55)
+	assert.Equal(Interval{10, 60, "b"}, next)
+	next, _ = tree.NextEndingAfter(79)
+	assert.Equal(Interval{5, 80, "f"}, next)
+	next, _ = tree.NextEndingAfter(85)
+	assert.Equal(Interval{70, 90, "e"}, next)
+	_, ok = tree.NextEndingAfter(90)
+	assert.False(ok)
+	tree.SoftRemove(40, 55, "c")
+	next, _ = tree.NextEndingAfter(15)
+	assert.Equal(Interval{45, 55, "d"}, next)
+	random := NewIntervalTree(0, 1000)
+	var intervals []Interval
+	for i := 0; i < 300; i++ {
+		start := rand.Intn(990)
+		interval := Interval{Start: start, End: start + 1 + rand.Intn(1000-start-1), Data: i}
+		intervals = append(intervals, interval)
+		random.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	random.Sort()
+	for x := -1; x < 1000; x += 7 {
+		expected, found := Interval{}, false
+		for _, interval := range intervals {
+			if interval.End > x && (!found || interval.End < expected.End ||
+				(interval.End == expected.End && interval.Start < expected.Start)) {
+				expected, found = interval, true
+			}
+		}
+		next, ok := random.NextEndingAfter(x)
+		assert.Equal(found, ok)
+		assert.Equal(expected.End, next.End)
+		assert.Equal(expected.Start, next.Start)
+	}
+}
+
+func TestQueryBounds(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 40, nil)
+	tree.AddInterval(30, 70, nil)
+	tree.AddInterval(35, 50, nil)
+	tree.AddInterval(60, 90, nil)
+	tree.Sort()
+	start, end, ok := tree.QueryBounds(37)
+	assert.True(ok)
+	assert.Equal(10, start)
+	assert.Equal(70, end)
+	for _, interval := range tree.queryIntervals(37) {
+		assert.LessOrEqual(start, interval.Start)
+		assert.GreaterOrEqual(end, interval.End)
+	}
+	start, end, ok = tree.QueryBounds(65)
+	assert.True(ok)
+	assert.Equal(30, start)
+	assert.Equal(90, end)
+	_, _, ok = tree.QueryBounds(95)
+	assert.False(ok)
+}
@@ -0,0 +1,247 @@
+package gointervaltree
+
+import (
+	"math"
+	"sort"
+)
+
+// Earliest method returns the interval with the smallest start (ties broken by the smallest end),
+// false is returned for an empty tree.
+func (tree *IntervalTree) Earliest() (Interval, bool) {
+	var result Interval
+	found := false
+	for _, r := range tree.records() {
+		if !found || r.Start < result.Start || (r.Start == result.Start && r.End < result.End) {
+			result = r.Interval
+			found = true
+		}
+	}
+	return result, found
+}
+
+// Latest method returns the interval with the largest end (ties broken by the largest start),
+// false is returned for an empty tree.
+func (tree *IntervalTree) Latest() (Interval, bool) {
+	var result Interval
+	found := false
+	for _, r := range tree.records() {
+		if !found || r.End > result.End || (r.End == result.End && r.Start > result.Start) {
+			result = r.Interval
+			found = true
+		}
+	}
+	return result, found
+}
+
+// MeanLength method returns the average end - start over all intervals maintained in the tree,
+// false is returned for an empty tree.
+func (tree *IntervalTree) MeanLength() (float64, bool) {
+	sum, count := 0, 0
+	for _, r := range tree.records() {
+		sum += r.End - r.Start
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return float64(sum) / float64(count), true
+}
+
+// LengthSummary struct defines the statistics of the end - start lengths of the intervals returned by LengthStats.
+// Mean is truncated to an int, Median is the lower median for an even count and StdDev is the population standard
+// deviation. OK is false and the other fields are zero for an empty tree.
+type LengthSummary struct {
+	Min    int
+	Max    int
+	Mean   int
+	Median int
+	StdDev float64
+	OK     bool
+}
+
+// LengthStats method returns the LengthSummary of all intervals maintained in the tree.
+func (tree *IntervalTree) LengthStats() LengthSummary {
+	var summary LengthSummary
+	var lengths []int
+	sum := 0
+	for _, r := range tree.records() {
+		length := r.End - r.Start
+		if len(lengths) == 0 || length < summary.Min {
+			summary.Min = length
+		}
+		if len(lengths) == 0 || length > summary.Max {
+			summary.Max = length
+		}
+		lengths = append(lengths, length)
+		sum += length
+	}
+	if len(lengths) == 0 {
+		return summary
+	}
+	sort.Ints(lengths)
+	mean := float64(sum) / float64(len(lengths))
+	variance := 0.0
+	for _, length := range lengths {
+		variance += (float64(length) - mean) * (float64(length) - mean)
+	}
+	summary.Mean = sum / len(lengths)
+	summary.Median = lengths[(len(lengths)-1)/2]
+	summary.StdDev = math.Sqrt(variance / float64(len(lengths)))
+	summary.OK = true
+	return summary
+}
+
+// DuplicateCoords method returns the groups of intervals sharing exactly the same [start, end) regardless of
+// their data, groups with a single interval are omitted. The groups are sorted by start then by end.
+func (tree *IntervalTree) DuplicateCoords() [][]Interval {
+	var result [][]Interval
+	intervals := tree.IterSorted()
+	for i := 0; i < len(intervals); {
+		j := i + 1
+		for j < len(intervals) && intervals[j].Start == intervals[i].Start && intervals[j].End == intervals[i].End {
+			j++
+		}
+		if j-i > 1 {
+			result = append(result, intervals[i:j:j])
+		}
+		i = j
+	}
+	return result
+}
+
+// MostContaining method returns the interval fully containing the largest number of other intervals maintained
+// in the tree along with that number, ties being broken by the smallest start then the smallest end. Intervals
+// with equal coordinates contain each other. False is returned for an empty tree.
+func (tree *IntervalTree) MostContaining() (Interval, int, bool) {
+	var best Interval
+	bestCount, found := 0, false
+	for _, candidate := range tree.records() {
+		low, high := tree.config.lower(candidate.Start), tree.config.upper(candidate.End)
+		count := 0
+		for _, r := range tree.queryRange(low, high) {
+			if r != candidate && low <= tree.config.lower(r.Start) && tree.config.upper(r.End) <= high {
+				count++
+			}
+		}
+		if !found || count > bestCount || (count == bestCount && (candidate.Start < best.Start ||
+			(candidate.Start == best.Start && candidate.End < best.End))) {
+			best, bestCount, found = candidate.Interval, count, true
+		}
+	}
+	return best, bestCount, found
+}
+
+// CountStartsBefore method returns the number of intervals in the tree with start < x. Subtrees lying entirely
+// on one side of x contribute their size and mid lists are binary searched, so only the lookup path of x is
+// visited.
+func (tree *IntervalTree) CountStartsBefore(x int) int {
+	// comparing the normalized starts keeps the order of the stored ones
+	x = tree.config.lower(x)
+	count := 0
+	for node := tree; node != nil; {
+		if !node.split {
+			if node.singleInterval != nil && !node.singleInterval.tombstone &&
+				node.config.lower(node.singleInterval.Start) < x {
+				count++
+			}
+			break
+		}
+		for _, r := range node.overflow {
+			if !r.tombstone && node.config.lower(r.Start) < x {
+				count++
+			}
+		}
+		k := sort.Search(len(node.midSortedByStart), func(i int) bool {
+			return node.config.lower(node.midSortedByStart[i].Start) >= x
+		})
+		count += k
+		if node.tombstones > 0 {
+			for _, r := range node.midSortedByStart[:k] {
+				if r.tombstone {
+					count--
+				}
+			}
+		}
+		if x > node.center {
+			if node.leftSubtree != nil {
+				count += node.leftSubtree.size
+			}
+			node = node.rightSubtree
+		} else {
+			node = node.leftSubtree
+		}
+	}
+	return count
+}
+
+// BucketCounts method returns the number of intervals starting in each fixed-width coordinate bucket keyed by
+// bucket index, i.e. floor(start / width). A non-positive width yields an empty map.
+func (tree *IntervalTree) BucketCounts(width int) map[int]int {
+	result := make(map[int]int)
+	if width <= 0 {
+		return result
+	}
+	for _, r := range tree.records() {
+		result[floorDiv(r.Start, width)]++
+	}
+	return result
+}
+
+// floorDiv function returns a / b rounded towards negative infinity for a positive b.
+func floorDiv(a int, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// EstimateSelectivity method returns the approximate fraction of intervals maintained in the tree overlapping
+// range [low, high) without scanning them. Node interval counts are used for subtrees lying entirely inside
+// or outside the range and mid lists are counted by binary search, so that only the nodes along the range
+// boundaries are visited. Tombstoned intervals may be counted, hence the result is an estimate.
+// The tree must be sorted.
+func (tree *IntervalTree) EstimateSelectivity(low int, high int) float64 {
+	if tree.size == 0 || (high-low) <= 0 {
+		return 0
+	}
+	return float64(tree.estimateCount(low, high)) / float64(tree.size)
+}
+
+// estimateCount method is a technical method used inside EstimateSelectivity for recursive counting.
+func (tree *IntervalTree) estimateCount(low int, high int) int {
+	if tree.size == 0 || high <= tree.min || tree.max <= low {
+		return 0
+	} else if low <= tree.min && tree.max <= high {
+		return tree.size
+	} else if !tree.split {
+		if tree.singleInterval != nil && tree.config.overlaps(tree.singleInterval.Interval, low, high) {
+			return 1
+		}
+		return 0
+	}
+	count := 0
+	for _, element := range tree.overflow {
+		if !element.tombstone && tree.config.overlaps(element.Interval, low, high) {
+			count++
+		}
+	}
+	if tree.leftSubtree != nil {
+		count += tree.leftSubtree.estimateCount(low, high)
+	}
+	if tree.rightSubtree != nil {
+		count += tree.rightSubtree.estimateCount(low, high)
+	}
+	if high <= tree.center {
+		count += sort.Search(len(tree.midSortedByStart), func(i int) bool {
+			return tree.config.lower(tree.midSortedByStart[i].Start) >= high
+		})
+	} else if low > tree.center {
+		count += sort.Search(len(tree.midSortedByEnd), func(i int) bool {
+			return tree.config.upper(tree.midSortedByEnd[i].End) <= low
+		})
+	} else {
+		count += len(tree.midSortedByStart) - tree.tombstones
+	}
+	return count
+}
@@ -0,0 +1,163 @@
+package gointervaltree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarliestLatest(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	_, ok := tree.Earliest()
+	assert.False(ok)
+	_, ok = tree.Latest()
+	assert.False(ok)
+	tree.AddInterval(40, 60, "a")
+	tree.AddInterval(5, 30, "b")
+	tree.AddInterval(5, 10, "c")
+	tree.AddInterval(70, 95, "d")
+	tree.AddInterval(80, 95, "e")
+	tree.AddInterval(20, 90, "f")
+	tree.Sort()
+	earliest, ok := tree.Earliest()
+	assert.True(ok)
+	assert.Equal(Interval{5, 10, "c"}, earliest)
+	latest, ok := tree.Latest()
+	assert.True(ok)
+	assert.Equal(Interval{80, 95, "e"}, latest)
+}
+
+func TestMeanLength(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	_, ok := tree.MeanLength()
+	assert.False(ok)
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(40, 60, nil)
+	tree.AddInterval(50, 51, nil)
+	tree.AddInterval(70, 75, nil)
+	tree.Sort()
+	mean, ok := tree.MeanLength()
+	assert.True(ok)
+	assert.Equal(9.0, mean)
+	tree.SoftRemove(50, 51, nil)
+	mean, _ = tree.MeanLength()
+	assert.InDelta(35.0/3, mean, 1e-9)
+}
+
+func TestLengthStats(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Equal(LengthSummary{}, tree.LengthStats())
+	for i, length := range []int{4, 9, 2, 5, 4, 7, 4, 5} {
+		tree.AddInterval(i*10, i*10+length, nil)
+	}
+	tree.Sort()
+	summary := tree.LengthStats()
+	assert.True(summary.OK)
+	assert.Equal(2, summary.Min)
+	assert.Equal(9, summary.Max)
+	assert.Equal(5, summary.Mean)
+	assert.Equal(4, summary.Median)
+	assert.InDelta(2.0, summary.StdDev, 1e-9)
+	tree.SoftRemove(10, 19, nil)
+	summary = tree.LengthStats()
+	assert.Equal(7, summary.Max)
+	assert.Equal(4, summary.Mean)
+	assert.Equal(4, summary.Median)
+}
+
+func TestDuplicateCoords(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range []Interval{{40, 60, "a"}, {10, 20, "x"}, {40, 60, "b"}, {40, 61, "c"},
+		{10, 20, "y"}, {70, 80, "z"}, {40, 60, "a"}} {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	groups := tree.DuplicateCoords()
+	assert.Equal(2, len(groups))
+	assert.ElementsMatch([]Interval{{10, 20, "x"}, {10, 20, "y"}}, groups[0])
+	assert.ElementsMatch([]Interval{{40, 60, "a"}, {40, 60, "b"}, {40, 60, "a"}}, groups[1])
+	assert.Empty(NewIntervalTree(0, 10).DuplicateCoords())
+}
+
+func TestMostContaining(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	_, _, ok := tree.MostContaining()
+	assert.False(ok)
+	for _, interval := range []Interval{{20, 80, "broad"}, {25, 30, nil}, {40, 60, "wide"}, {45, 50, nil},
+		{55, 60, nil}, {70, 80, nil}, {75, 90, nil}, {0, 10, nil}} {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	interval, count, ok := tree.MostContaining()
+	assert.True(ok)
+	assert.Equal(Interval{20, 80, "broad"}, interval)
+	assert.Equal(5, count)
+	single := NewIntervalTree(0, 100)
+	single.AddInterval(10, 20, nil)
+	interval, count, ok = single.MostContaining()
+	assert.True(ok)
+	assert.Equal(Interval{10, 20, nil}, interval)
+	assert.Equal(0, count)
+}
+
+func TestCountStartsBefore(t *testing.T) {
+	assert := assert.New(t)
+	for _, mode := range []IntervalMode{ClosedOpen, Open} {
+		tree := NewIntervalTree(0, 1000, WithIntervalMode(mode))
+		for i := 0; i < 300; i++ {
+			tree.AddInterval((i*37)%900, (i*37)%900+2+(i*13)%200, i)
+		}
+		tree.SoftRemove(37, 52, 1)
+		tree.SoftRemove(0, 2, 0)
+		tree.Sort()
+		sorted := tree.IterSorted()
+		for x := -1; x <= 1000; x++ {
+			expected := 0
+			for _, interval := range sorted {
+				if interval.Start < x {
+					expected++
+				}
+			}
+			assert.Equal(expected, tree.CountStartsBefore(x))
+		}
+	}
+}
+
+func TestBucketCounts(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(-100, 100)
+	for _, start := range []int{0, 9, 10, 19, 20, 55, -1, -10, -11} {
+		tree.AddInterval(start, start+5, nil)
+	}
+	tree.Sort()
+	assert.Equal(map[int]int{0: 2, 1: 2, 2: 1, 5: 1, -1: 2, -2: 1}, tree.BucketCounts(10))
+	assert.Empty(tree.BucketCounts(0))
+	assert.Empty(tree.BucketCounts(-5))
+}
+
+func TestEstimateSelectivity(t *testing.T) {
+	assert := assert.New(t)
+	random := rand.New(rand.NewSource(7))
+	tree := NewIntervalTree(0, 10000)
+	for i := 0; i < 2000; i++ {
+		start := random.Intn(9900)
+		tree.AddInterval(start, start+1+random.Intn(100), i)
+	}
+	tree.Sort()
+	for i := 0; i < 200; i++ {
+		low := random.Intn(10000)
+		high := low + 1 + random.Intn(2000)
+		actual := float64(len(tree.QueryRange(low, high))) / float64(tree.Len())
+		assert.InDelta(actual, tree.EstimateSelectivity(low, high), 0.05)
+	}
+	assert.Equal(1.0, tree.EstimateSelectivity(0, 10000))
+	assert.Equal(0.0, tree.EstimateSelectivity(20000, 30000))
+	assert.Equal(0.0, tree.EstimateSelectivity(50, 50))
+	assert.Equal(0.0, NewIntervalTree(0, 10).EstimateSelectivity(0, 10))
+}
@@ -0,0 +1,430 @@
+package gointervaltree
+
+import (
+	"log"
+	"sort"
+)
+
+// event struct defines a coordinate where the number of active intervals changes by delta during a sweep.
+type event struct {
+	at    int
+	delta int
+}
+
+// sortEvents function sorts sweep events by coordinate placing closing events (negative delta) before opening
+// ones at the same coordinate, which matches the half-open [start, end) semantics.
+func sortEvents(events []event) {
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return events[i].delta < events[j].delta
+	})
+}
+
+// boundaryEvents method is a technical method returning the sorted opening and closing events of all intervals
+// maintained in the tree.
+func (tree *IntervalTree) boundaryEvents() []event {
+	intervals := tree.intervals()
+	events := make([]event, 0, 2*len(intervals))
+	for _, interval := range intervals {
+		events = append(events, event{at: interval.Start, delta: 1}, event{at: interval.End, delta: -1})
+	}
+	sortEvents(events)
+	return events
+}
+
+// DensestWindow method returns the leftmost start p >= min of a window [p, p+w) overlapping the largest number
+// of intervals maintained in the tree along with that number. For an empty tree or a non-positive width the tree
+// start and zero count are returned.
+func (tree *IntervalTree) DensestWindow(w int) (start int, count int) {
+	start = tree.min
+	if w <= 0 {
+		return start, 0
+	}
+	intervals := tree.intervals()
+	events := make([]event, 0, 2*len(intervals))
+	for _, interval := range intervals {
+		// window [p, p+w) overlaps [s, e) for every p in [s-w+1, e), windows never start before min
+		opening := interval.Start - w + 1
+		if opening < tree.min {
+			opening = tree.min
+		}
+		events = append(events, event{at: opening, delta: 1}, event{at: interval.End, delta: -1})
+	}
+	sortEvents(events)
+	active := 0
+	for i, e := range events {
+		active += e.delta
+		if i+1 < len(events) && events[i+1].at == e.at {
+			continue
+		}
+		if active > count {
+			start, count = e.at, active
+		}
+	}
+	return start, count
+}
+
+// mergeIntervals function returns the sorted, non-overlapping union of intervals with nil data,
+// touching intervals are merged as well.
+func mergeIntervals(intervals []Interval) []Interval {
+	sorted := append([]Interval{}, intervals...)
+	sortIntervals(sorted)
+	var result []Interval
+	for _, interval := range sorted {
+		if n := len(result); n > 0 && interval.Start <= result[n-1].End {
+			if interval.End > result[n-1].End {
+				result[n-1].End = interval.End
+			}
+			continue
+		}
+		result = append(result, Interval{Start: interval.Start, End: interval.End})
+	}
+	return result
+}
+
+// CoverageFraction method returns the fraction of range [low, high) covered by the union of the intervals
+// maintained in the tree, i.e. a value in [0, 1]. An empty range yields 0.
+func (tree *IntervalTree) CoverageFraction(low int, high int) float64 {
+	if (high - low) <= 0 {
+		return 0
+	}
+	covered := 0
+	for _, interval := range mergeIntervals(tree.QueryRange(low, high)) {
+		covered += overlapLength(interval, low, high)
+	}
+	return float64(covered) / float64(high-low)
+}
+
+// CoverageLength method returns the total length of the union of the intervals maintained in the tree.
+func (tree *IntervalTree) CoverageLength() int {
+	covered := 0
+	for _, interval := range tree.FlattenUnion() {
+		covered += interval.End - interval.Start
+	}
+	return covered
+}
+
+// FlattenUnion method returns the union of the intervals maintained in the tree as the minimal sorted set of
+// non-overlapping [start, end) ranges with nil data, touching intervals being merged as well.
+func (tree *IntervalTree) FlattenUnion() []Interval {
+	return mergeIntervals(tree.intervals())
+}
+
+// Gaps method returns the sorted [start, end) ranges of [min, max) of the tree covered by no interval,
+// with nil data.
+func (tree *IntervalTree) Gaps() []Interval {
+	var result []Interval
+	position := tree.min
+	for _, covered := range tree.FlattenUnion() {
+		if covered.Start > position {
+			result = append(result, Interval{Start: position, End: covered.Start})
+		}
+		if covered.End > position {
+			position = covered.End
+		}
+	}
+	if position < tree.max {
+		result = append(result, Interval{Start: position, End: tree.max})
+	}
+	return result
+}
+
+// FirstFreeGap method returns the smallest p such that [p, p+width) lies within [min, max) of the tree and
+// overlaps no interval, false is returned if there is no such range or width is not positive.
+func (tree *IntervalTree) FirstFreeGap(width int) (start int, ok bool) {
+	if width <= 0 {
+		return 0, false
+	}
+	for _, gap := range tree.Gaps() {
+		if gap.End-gap.Start >= width {
+			return gap.Start, true
+		}
+	}
+	return 0, false
+}
+
+// NearestGap method returns the range of Gaps closest to point x, which is the gap containing x if x is covered
+// by no interval. Of two gaps at the same distance the left one is returned, false is returned if there are no gaps.
+func (tree *IntervalTree) NearestGap(x int) (Interval, bool) {
+	var nearest Interval
+	found := false
+	best := 0
+	for _, gap := range tree.Gaps() {
+		distance := distanceTo(x, gap.Start, gap.End)
+		if !found || distance < best {
+			nearest, best, found = gap, distance, true
+		}
+	}
+	return nearest, found
+}
+
+// FillRatio method returns the fraction of [min, max) of the tree covered by the union of its intervals,
+// i.e. CoverageLength() / (max - min) in [0, 1]. Bounds spanning no points, which construction rules out,
+// yield 0.
+func (tree *IntervalTree) FillRatio() float64 {
+	if tree.max <= tree.min {
+		return 0
+	}
+	return float64(tree.CoverageLength()) / float64(tree.max-tree.min)
+}
+
+// Breakpoints method returns the sorted, deduplicated union of all interval starts and ends, i.e. the coordinates
+// where the set of active intervals may change.
+func (tree *IntervalTree) Breakpoints() []int {
+	intervals := tree.intervals()
+	points := make([]int, 0, 2*len(intervals))
+	for _, interval := range intervals {
+		points = append(points, interval.Start, interval.End)
+	}
+	sort.Ints(points)
+	result := points[:0]
+	for i, point := range points {
+		if i == 0 || point != points[i-1] {
+			result = append(result, point)
+		}
+	}
+	return result
+}
+
+// SortedStarts method returns the starts of all intervals maintained in the tree in ascending order,
+// duplicates included.
+func (tree *IntervalTree) SortedStarts() []int {
+	intervals := tree.intervals()
+	starts := make([]int, len(intervals))
+	for i, interval := range intervals {
+		starts[i] = interval.Start
+	}
+	sort.Ints(starts)
+	return starts
+}
+
+// SortedEnds method returns the ends of all intervals maintained in the tree in ascending order,
+// duplicates included.
+func (tree *IntervalTree) SortedEnds() []int {
+	intervals := tree.intervals()
+	ends := make([]int, len(intervals))
+	for i, interval := range intervals {
+		ends[i] = interval.End
+	}
+	sort.Ints(ends)
+	return ends
+}
+
+// Clusters method partitions the intervals maintained in the tree into maximal groups connected through chained
+// overlaps, each group sorted by start then by end and the groups ordered by their first start. Since intervals
+// are half-open, touching intervals like [0, 10) and [10, 20) do not overlap and fall into different clusters.
+func (tree *IntervalTree) Clusters() [][]Interval {
+	var clusters [][]Interval
+	var maxEnd int
+	for _, interval := range tree.IterSorted() {
+		if len(clusters) == 0 || interval.Start >= maxEnd {
+			clusters = append(clusters, nil)
+			maxEnd = interval.End
+		}
+		last := len(clusters) - 1
+		clusters[last] = append(clusters[last], interval)
+		if interval.End > maxEnd {
+			maxEnd = interval.End
+		}
+	}
+	return clusters
+}
+
+// LongestIsolated method returns the longest interval which overlaps no other interval maintained in the tree,
+// ties being broken by the smallest start. Touching intervals do not overlap. False is returned if there is no
+// such interval.
+func (tree *IntervalTree) LongestIsolated() (Interval, bool) {
+	var longest Interval
+	found := false
+	intervals := tree.IterSorted()
+	maxEnd := 0
+	for i, interval := range intervals {
+		isolated := (i == 0 || maxEnd <= interval.Start) &&
+			(i == len(intervals)-1 || intervals[i+1].Start >= interval.End)
+		if isolated && (!found || interval.End-interval.Start > longest.End-longest.Start) {
+			longest, found = interval, true
+		}
+		if i == 0 || interval.End > maxEnd {
+			maxEnd = interval.End
+		}
+	}
+	return longest, found
+}
+
+// CoveragePoint struct defines the number of intervals active right from a breakpoint on, i.e. over [At, next At).
+type CoveragePoint struct {
+	At    int
+	Count int
+}
+
+// CoverageProfile method returns a CoveragePoint for each breakpoint in ascending order, i.e. for each distinct
+// interval start and end, the last one always having a zero count. Adjacent points with equal counts, e.g. where
+// one interval ends and another starts, are kept rather than merged so that every breakpoint is reported.
+func (tree *IntervalTree) CoverageProfile() []CoveragePoint {
+	var result []CoveragePoint
+	events := tree.boundaryEvents()
+	active := 0
+	for i, e := range events {
+		active += e.delta
+		if i+1 < len(events) && events[i+1].at == e.at {
+			continue
+		}
+		result = append(result, CoveragePoint{At: e.at, Count: active})
+	}
+	return result
+}
+
+// Step struct defines the number of intervals active over [At, next At) in a step function.
+type Step struct {
+	At     int
+	Active int
+}
+
+// OccupancySteps method returns the number of active intervals as a run-length encoded step function sorted by
+// coordinate. Unlike CoverageProfile, breakpoints not changing the number of active intervals are omitted,
+// the last step always has zero active intervals.
+func (tree *IntervalTree) OccupancySteps() []Step {
+	var result []Step
+	for _, point := range tree.CoverageProfile() {
+		if len(result) > 0 && result[len(result)-1].Active == point.Count {
+			continue
+		}
+		result = append(result, Step{At: point.At, Active: point.Count})
+	}
+	return result
+}
+
+// MaxOverlapRegions method returns the sorted, disjoint [start, end) ranges over which the number of overlapping
+// intervals equals its maximum, adjacent ranges being merged. An empty tree yields no ranges.
+func (tree *IntervalTree) MaxOverlapRegions() []Interval {
+	var result []Interval
+	profile := tree.CoverageProfile()
+	peak := 0
+	for _, point := range profile {
+		if point.Count > peak {
+			peak = point.Count
+		}
+	}
+	if peak == 0 {
+		return result
+	}
+	for i, point := range profile {
+		if point.Count != peak {
+			continue
+		}
+		// the last point has a zero count, so a peak point is always followed by another one
+		end := profile[i+1].At
+		if last := len(result) - 1; last >= 0 && result[last].End == point.At {
+			result[last].End = end
+		} else {
+			result = append(result, Interval{Start: point.At, End: end})
+		}
+	}
+	return result
+}
+
+// OverlappingPairCount method returns the number of unordered pairs of intervals maintained in the tree which
+// overlap each other, computed by a sweep adding the number of active intervals whenever an interval starts.
+func (tree *IntervalTree) OverlappingPairCount() int64 {
+	var count int64
+	active := 0
+	for _, e := range tree.boundaryEvents() {
+		if e.delta > 0 {
+			count += int64(active)
+		}
+		active += e.delta
+	}
+	return count
+}
+
+// WindowCursor struct defines a window [start, start+width) sliding forward over a snapshot of the intervals of
+// a tree, keeping track of the intervals overlapping it.
+type WindowCursor struct {
+	width     int
+	start     int
+	intervals []Interval
+	next      int
+	active    []Interval
+}
+
+// WindowCursor method returns a WindowCursor over the intervals currently maintained in the tree with its window
+// at [min, min+width). Later changes of the tree are not reflected by the cursor.
+func (tree *IntervalTree) WindowCursor(width int) *WindowCursor {
+	if width <= 0 {
+		log.Panic("AssertionError: window width must be positive")
+	}
+	cursor := &WindowCursor{width: width, start: tree.min, intervals: tree.IterSorted()}
+	cursor.enter()
+	return cursor
+}
+
+// Active method returns the intervals overlapping the current window sorted by start, then by end.
+func (cursor *WindowCursor) Active() []Interval {
+	return append([]Interval{}, cursor.active...)
+}
+
+// Advance method moves the window forward by the given non-negative distance and returns the intervals which
+// started and stopped overlapping it, each sorted by start then by end. Intervals skipped over entirely by
+// a large move are reported neither as entered nor as exited.
+func (cursor *WindowCursor) Advance(by int) (entered []Interval, exited []Interval) {
+	if by < 0 {
+		log.Panic("AssertionError: window cursor can only advance forward")
+	}
+	cursor.start += by
+	kept := cursor.active[:0]
+	for _, interval := range cursor.active {
+		if interval.End > cursor.start {
+			kept = append(kept, interval)
+		} else {
+			exited = append(exited, interval)
+		}
+	}
+	cursor.active = kept
+	return cursor.enter(), exited
+}
+
+// enter method is a technical method moving the intervals starting before the end of the window into the active
+// set and returning those which overlap the window.
+func (cursor *WindowCursor) enter() []Interval {
+	var entered []Interval
+	for ; cursor.next < len(cursor.intervals); cursor.next++ {
+		interval := cursor.intervals[cursor.next]
+		if interval.Start >= cursor.start+cursor.width {
+			break
+		}
+		if interval.End > cursor.start {
+			entered = append(entered, interval)
+		}
+	}
+	if len(entered) > 0 {
+		cursor.active = append(cursor.active, entered...)
+		sortIntervals(cursor.active)
+	}
+	return entered
+}
+
+// QueryByLayer method returns the intervals overlapping given point grouped by layer. Layers are assigned over
+// these intervals greedily in the order of their starts, then of their ends, each interval taking the lowest layer
+// whose intervals it does not overlap. Intervals overlapping the same point always overlap each other, so the
+// result holds one interval per layer and as many layers as intervals overlap x. The layer of an interval may thus
+// differ between points.
+func (tree *IntervalTree) QueryByLayer(x int) [][]Interval {
+	var result [][]Interval
+	var layerEnds []int
+	for _, r := range sortedByStart(tree.query(x)) {
+		layer := 0
+		for layer < len(layerEnds) && layerEnds[layer] > r.Start {
+			layer++
+		}
+		if layer == len(layerEnds) {
+			layerEnds = append(layerEnds, r.End)
+			result = append(result, nil)
+		} else {
+			layerEnds[layer] = r.End
+		}
+		result[layer] = append(result[layer], r.Interval)
+	}
+	return result
+}
@@ -0,0 +1,332 @@
+package gointervaltree
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDensestWindow(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range [][]int{{5, 8}, {10, 12}, {30, 32}, {33, 35}, {36, 40}, {38, 39}, {60, 61}, {90, 95}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	start, count := tree.DensestWindow(5)
+	assert.Equal(34, start)
+	assert.Equal(3, count)
+	start, count = tree.DensestWindow(8)
+	assert.Equal(31, start)
+	assert.Equal(4, count)
+	start, count = tree.DensestWindow(1)
+	assert.Equal(38, start)
+	assert.Equal(2, count)
+	start, count = tree.DensestWindow(100)
+	assert.Equal(0, start)
+	assert.Equal(8, count)
+	start, count = tree.DensestWindow(0)
+	assert.Equal(0, start)
+	assert.Equal(0, count)
+	start, count = NewIntervalTree(0, 100).DensestWindow(5)
+	assert.Equal(0, start)
+	assert.Equal(0, count)
+}
+
+func TestCoverageFraction(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, nil)
+	tree.AddInterval(20, 40, nil)
+	tree.AddInterval(25, 26, nil)
+	tree.AddInterval(60, 70, nil)
+	tree.Sort()
+	assert.Equal(1.0, tree.CoverageFraction(15, 35))
+	assert.Equal(0.5, tree.CoverageFraction(30, 50))
+	assert.Equal(0.375, tree.CoverageFraction(35, 75))
+	assert.Equal(0.0, tree.CoverageFraction(40, 60))
+	assert.Equal(0.4, tree.CoverageFraction(0, 100))
+	assert.Equal(0.0, tree.CoverageFraction(50, 50))
+	assert.Equal(0.0, tree.CoverageFraction(50, 40))
+}
+
+func TestFlattenUnion(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.FlattenUnion())
+	for _, interval := range [][]int{{10, 30}, {15, 20}, {25, 40}, {40, 45}, {50, 60}, {52, 53}, {70, 71}} {
+		tree.AddInterval(interval[0], interval[1], "data")
+	}
+	tree.Sort()
+	assert.Equal([]Interval{{10, 45, nil}, {50, 60, nil}, {70, 71, nil}}, tree.FlattenUnion())
+}
+
+func TestFirstFreeGap(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	start, ok := tree.FirstFreeGap(100)
+	assert.True(ok)
+	assert.Equal(0, start)
+	tree.AddInterval(5, 20, nil)
+	tree.AddInterval(22, 30, nil)
+	tree.AddInterval(25, 60, nil)
+	tree.AddInterval(70, 95, nil)
+	tree.Sort()
+	start, ok = tree.FirstFreeGap(5)
+	assert.True(ok)
+	assert.Equal(0, start)
+	start, ok = tree.FirstFreeGap(2)
+	assert.True(ok)
+	assert.Equal(0, start)
+	start, ok = tree.FirstFreeGap(6)
+	assert.True(ok)
+	assert.Equal(60, start)
+	start, ok = tree.FirstFreeGap(10)
+	assert.True(ok)
+	assert.Equal(60, start)
+	_, ok = tree.FirstFreeGap(11)
+	assert.False(ok)
+	_, ok = tree.FirstFreeGap(0)
+	assert.False(ok)
+	tree.AddInterval(0, 5, nil)
+	tree.Sort()
+	start, _ = tree.FirstFreeGap(2)
+	assert.Equal(20, start)
+	start, _ = tree.FirstFreeGap(5)
+	assert.Equal(60, start)
+}
+
+func TestNearestGap(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(0, 20, nil)
+	tree.AddInterval(25, 50, nil)
+	tree.AddInterval(40, 90, nil)
+	tree.Sort()
+	assert.Equal([]Interval{{20, 25, nil}, {90, 100, nil}}, tree.Gaps())
+	gap, ok := tree.NearestGap(30)
+	assert.True(ok)
+	assert.Equal(Interval{20, 25, nil}, gap)
+	gap, ok = tree.NearestGap(70)
+	assert.True(ok)
+	assert.Equal(Interval{90, 100, nil}, gap)
+	gap, ok = tree.NearestGap(22)
+	assert.True(ok)
+	assert.Equal(Interval{20, 25, nil}, gap)
+	gap, ok = tree.NearestGap(-10)
+	assert.True(ok)
+	assert.Equal(Interval{20, 25, nil}, gap)
+	tree.AddInterval(20, 25, nil)
+	tree.AddInterval(90, 100, nil)
+	_, ok = tree.NearestGap(30)
+	assert.False(ok)
+}
+
+func TestFillRatio(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(100, 300)
+	assert.Equal(0.0, tree.FillRatio())
+	tree.AddInterval(100, 150, nil)
+	tree.AddInterval(120, 160, nil)
+	tree.AddInterval(200, 210, nil)
+	tree.AddInterval(205, 206, nil)
+	tree.AddInterval(250, 300, nil)
+	tree.Sort()
+	assert.Equal(120, tree.CoverageLength())
+	assert.InDelta(0.6, tree.FillRatio(), 1e-9)
+	assert.InDelta(tree.CoverageFraction(100, 300), tree.FillRatio(), 1e-9)
+	assert.Equal(0.0, (&IntervalTree{config: new(treeConfig)}).FillRatio())
+}
+
+func TestBreakpoints(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, nil)
+	tree.AddInterval(20, 40, nil)
+	tree.AddInterval(30, 35, nil)
+	tree.AddInterval(10, 50, nil)
+	tree.Sort()
+	assert.Equal([]int{10, 20, 30, 35, 40, 50}, tree.Breakpoints())
+	assert.Empty(NewIntervalTree(0, 100).Breakpoints())
+}
+
+func TestSortedStartsAndEnds(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.SortedStarts())
+	assert.Empty(tree.SortedEnds())
+	for _, interval := range [][]int{{40, 60}, {10, 90}, {10, 20}, {70, 75}, {50, 51}, {40, 45}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	var starts, ends []int
+	for _, interval := range tree.IterSorted() {
+		starts = append(starts, interval.Start)
+		ends = append(ends, interval.End)
+	}
+	sort.Ints(ends)
+	assert.Equal(starts, tree.SortedStarts())
+	assert.Equal(ends, tree.SortedEnds())
+	assert.Equal([]int{10, 10, 40, 40, 50, 70}, tree.SortedStarts())
+	assert.Equal([]int{20, 45, 51, 60, 75, 90}, tree.SortedEnds())
+}
+
+func TestClusters(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.Clusters())
+	for _, interval := range []Interval{{70, 80, "c"}, {10, 30, "a"}, {12, 15, "a"}, {25, 40, "a"},
+		{40, 45, "b"}, {42, 50, "b"}, {75, 90, "c"}} {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	assert.Equal([][]Interval{
+		{{10, 30, "a"}, {12, 15, "a"}, {25, 40, "a"}},
+		{{40, 45, "b"}, {42, 50, "b"}},
+		{{70, 80, "c"}, {75, 90, "c"}},
+	}, tree.Clusters())
+}
+
+func TestLongestIsolated(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 200)
+	_, ok := tree.LongestIsolated()
+	assert.False(ok)
+	for _, interval := range []Interval{{0, 50, "overlapped"}, {10, 20, "inside"}, {50, 60, "touching"},
+		{70, 75, "short"}, {100, 180, "overlapping"}, {170, 190, "overlapping"}, {190, 200, "last"}} {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	longest, ok := tree.LongestIsolated()
+	assert.True(ok)
+	assert.Equal(Interval{50, 60, "touching"}, longest)
+	tree.RemoveByCoords(50, 60)
+	tree.RemoveByCoords(10, 20)
+	longest, _ = tree.LongestIsolated()
+	assert.Equal(Interval{0, 50, "overlapped"}, longest)
+	crowded := NewIntervalTree(0, 100)
+	crowded.AddInterval(10, 30, nil)
+	crowded.AddInterval(20, 40, nil)
+	crowded.Sort()
+	_, ok = crowded.LongestIsolated()
+	assert.False(ok)
+}
+
+func TestCoverageProfile(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.CoverageProfile())
+	for _, interval := range [][]int{{10, 30}, {20, 40}, {25, 35}, {40, 50}, {60, 70}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	assert.Equal([]CoveragePoint{{10, 1}, {20, 2}, {25, 3}, {30, 2}, {35, 1}, {40, 1}, {50, 0}, {60, 1}, {70, 0}},
+		tree.CoverageProfile())
+	assert.Equal(len(tree.Breakpoints()), len(tree.CoverageProfile()))
+}
+
+func TestOccupancySteps(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.OccupancySteps())
+	for _, interval := range [][]int{{10, 30}, {20, 40}, {25, 35}, {40, 50}, {60, 70}, {70, 75}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	assert.Equal([]Step{{10, 1}, {20, 2}, {25, 3}, {30, 2}, {35, 1}, {50, 0}, {60, 1}, {75, 0}},
+		tree.OccupancySteps())
+}
+
+func TestMaxOverlapRegions(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 200)
+	assert.Empty(tree.MaxOverlapRegions())
+	for _, interval := range [][]int{{10, 30}, {20, 40}, {35, 50}, {100, 130}, {110, 120}, {120, 125}, {150, 160}} {
+		tree.AddInterval(interval[0], interval[1], nil)
+	}
+	tree.Sort()
+	assert.Equal([]Interval{{20, 30, nil}, {35, 40, nil}, {110, 125, nil}}, tree.MaxOverlapRegions())
+	tree.AddInterval(112, 114, nil)
+	tree.Sort()
+	assert.Equal([]Interval{{112, 114, nil}}, tree.MaxOverlapRegions())
+}
+
+func TestOverlappingPairCount(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 200)
+	assert.Equal(int64(0), tree.OverlappingPairCount())
+	for i := 0; i < 60; i++ {
+		tree.AddInterval((i*37)%180, (i*37)%180+1+(i*13)%25, nil)
+	}
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(10, 20, nil)
+	tree.AddInterval(20, 30, nil)
+	tree.Sort()
+	intervals := tree.intervals()
+	var expected int64
+	for i := range intervals {
+		for j := i + 1; j < len(intervals); j++ {
+			if intervals[i].Start < intervals[j].End && intervals[j].Start < intervals[i].End {
+				expected++
+			}
+		}
+	}
+	assert.Greater(expected, int64(0))
+	assert.Equal(expected, tree.OverlappingPairCount())
+}
+
+func TestWindowCursor(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	for _, interval := range []Interval{{0, 5, "a"}, {3, 12, "b"}, {8, 10, "c"}, {14, 30, "d"}, {16, 17, "e"},
+		{50, 60, "f"}} {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	cursor := tree.WindowCursor(5)
+	assert.Equal([]Interval{{0, 5, "a"}, {3, 12, "b"}}, cursor.Active())
+	entered, exited := cursor.Advance(5)
+	assert.Equal([]Interval{{8, 10, "c"}}, entered)
+	assert.Equal([]Interval{{0, 5, "a"}}, exited)
+	entered, exited = cursor.Advance(5)
+	assert.Equal([]Interval{{14, 30, "d"}}, entered)
+	assert.Equal([]Interval{{8, 10, "c"}}, exited)
+	entered, exited = cursor.Advance(3)
+	assert.Equal([]Interval{{16, 17, "e"}}, entered)
+	assert.Equal([]Interval{{3, 12, "b"}}, exited)
+	entered, exited = cursor.Advance(0)
+	assert.Empty(entered)
+	assert.Empty(exited)
+	entered, exited = cursor.Advance(50)
+	assert.Empty(entered)
+	assert.Equal([]Interval{{14, 30, "d"}, {16, 17, "e"}}, exited)
+	assert.Empty(cursor.Active())
+	entered, _ = cursor.Advance(0)
+	assert.Empty(entered)
+	assert.Panics(func() { cursor.Advance(-1) })
+	assert.Panics(func() { tree.WindowCursor(0) })
+}
+
+func TestQueryByLayer(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	assert.Empty(tree.QueryByLayer(50))
+	for _, interval := range []Interval{{0, 30, "a"}, {10, 20, "b"}, {20, 40, "c"}, {30, 50, "d"},
+		{35, 45, "e"}, {45, 60, "f"}} {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	assert.Equal([][]Interval{{{0, 30, "a"}}, {{10, 20, "b"}}}, tree.QueryByLayer(15))
+	assert.Equal([][]Interval{{{20, 40, "c"}}, {{30, 50, "d"}}, {{35, 45, "e"}}}, tree.QueryByLayer(37))
+	assert.Equal([][]Interval{{{45, 60, "f"}}}, tree.QueryByLayer(55))
+	assert.Equal([][]Interval{{{30, 50, "d"}}, {{45, 60, "f"}}}, tree.QueryByLayer(47))
+	assert.Empty(tree.QueryByLayer(70))
+	for x := 0; x < 100; x++ {
+		layers := tree.QueryByLayer(x)
+		for _, layer := range layers {
+			assert.Equal(1, len(layer))
+		}
+		assert.Equal(len(tree.Query(x)), len(layers))
+	}
+}
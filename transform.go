@@ -0,0 +1,96 @@
+package gointervaltree
+
+import "fmt"
+
+// derive method is a technical method creating an empty tree over [min, max) configured the same way as the tree.
+func (tree *IntervalTree) derive(min int, max int) *IntervalTree {
+	config := *tree.config
+	return newNode(min, max, &config)
+}
+
+// Trim method returns a new sorted tree over [low, high) containing the intervals of the tree clipped to that
+// range, i.e. [max(start, low), min(end, high)), intervals left empty after clipping are dropped.
+func (tree *IntervalTree) Trim(low int, high int) *IntervalTree {
+	result := tree.derive(low, high)
+	for _, interval := range tree.QueryRange(low, high) {
+		if interval.Start < low {
+			interval.Start = low
+		}
+		if interval.End > high {
+			interval.End = high
+		}
+		result.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	result.Sort()
+	return result
+}
+
+// Subtree method returns a new sorted tree containing unclipped copies of the intervals overlapping range
+// [low, high), the original tree is left untouched. Unlike Trim, the new tree spans [low, high) widened
+// to enclose the copied intervals so that they stay within its bounds.
+func (tree *IntervalTree) Subtree(low int, high int) *IntervalTree {
+	intervals := tree.QueryRange(low, high)
+	min, max := low, high
+	for _, interval := range intervals {
+		if interval.Start < min {
+			min = interval.Start
+		}
+		if interval.End > max {
+			max = interval.End
+		}
+	}
+	result := tree.derive(min, max)
+	for _, interval := range intervals {
+		result.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	result.Sort()
+	return result
+}
+
+// Compress method returns a new sorted tree in which every coordinate is replaced by its rank among the distinct
+// interval endpoints, along with the mapping from original to compressed coordinates. Since the ranks keep the
+// order of the endpoints, overlaps between the intervals and with endpoint queries are preserved, except in Open
+// mode where they also depend on the integer points between endpoints. The new tree spans [0, rank count + 1).
+func (tree *IntervalTree) Compress() (*IntervalTree, map[int]int) {
+	mapping := make(map[int]int)
+	points := tree.Breakpoints()
+	for rank, point := range points {
+		mapping[point] = rank
+	}
+	result := tree.derive(0, len(points)+1)
+	for _, interval := range tree.intervals() {
+		result.AddInterval(mapping[interval.Start], mapping[interval.End], interval.Data)
+	}
+	result.Sort()
+	return result, mapping
+}
+
+// Shift method returns a new sorted tree over [min+delta, max+delta) in which every interval of the tree is
+// translated by delta keeping its data.
+func (tree *IntervalTree) Shift(delta int) *IntervalTree {
+	result := tree.derive(tree.min+delta, tree.max+delta)
+	for _, interval := range tree.intervals() {
+		result.AddInterval(interval.Start+delta, interval.End+delta, interval.Data)
+	}
+	result.Sort()
+	return result
+}
+
+// Scale method returns a new sorted tree over [min*factor, max*factor) in which every coordinate of the tree is
+// multiplied by factor keeping the data. An error is returned for a non-positive factor and for a factor with
+// which the bounds overflow int, since the intervals lie within the bounds their coordinates cannot overflow then.
+func (tree *IntervalTree) Scale(factor int) (*IntervalTree, error) {
+	if factor <= 0 {
+		return nil, fmt.Errorf("scale factor must be positive, got %d", factor)
+	}
+	min, max := tree.min*factor, tree.max*factor
+	if min/factor != tree.min || max/factor != tree.max {
+		return nil, fmt.Errorf("scaling bounds [%d, %d) by %d overflows", tree.min, tree.max, factor)
+	}
+	result := tree.derive(min, max)
+	for _, interval := range tree.intervals() {
+		result.AddInterval(interval.Start*factor, interval.End*factor, interval.Data)
+	}
+	result.Sort()
+	return result, nil
+}
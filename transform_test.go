@@ -0,0 +1,126 @@
+package gointervaltree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrim(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, "left straddling")
+	tree.AddInterval(30, 40, "inside")
+	tree.AddInterval(45, 70, "right straddling")
+	tree.AddInterval(0, 100, "enclosing")
+	tree.AddInterval(0, 20, "outside left")
+	tree.AddInterval(50, 60, "outside right")
+	tree.Sort()
+	trimmed := tree.Trim(20, 50)
+	assert.Equal([]Interval{{20, 30, "left straddling"}, {20, 50, "enclosing"}, {30, 40, "inside"},
+		{45, 50, "right straddling"}}, trimmed.IterSorted())
+	assert.Equal(2, len(trimmed.Query(47)))
+	assert.Equal(6, tree.Len())
+	assert.Empty(tree.Trim(200, 300).IterSorted())
+}
+
+func TestSubtree(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, "a")
+	tree.AddInterval(30, 40, "b")
+	tree.AddInterval(45, 70, "c")
+	tree.AddInterval(0, 20, "d")
+	tree.AddInterval(75, 80, "e")
+	tree.Sort()
+	subtree := tree.Subtree(20, 50)
+	assert.Equal([]Interval{{10, 30, "a"}, {30, 40, "b"}, {45, 70, "c"}}, subtree.IterSorted())
+	assert.Equal(10, subtree.min)
+	assert.Equal(70, subtree.max)
+	assert.Equal([]interface{}{[]interface{}{45, 70, "c"}}, subtree.Query(65))
+	assert.Empty(subtree.Query(5))
+	assert.Equal(5, tree.Len())
+	assert.Equal(2, len(tree.Query(15)))
+}
+
+func TestCompress(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 1<<40)
+	timestamps := []Interval{{1000000, 5000000, "a"}, {3000000, 9000000, "b"}, {9000000, 12000000, "c"},
+		{1000000, 2000000, "d"}, {20000000, 30000000, "e"}}
+	for _, interval := range timestamps {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.Sort()
+	compressed, mapping := tree.Compress()
+	assert.Equal(tree.Len(), compressed.Len())
+	assert.Equal(0, compressed.min)
+	assert.Equal(len(mapping)+1, compressed.max)
+	assert.Equal(0, mapping[1000000])
+	assert.Equal(len(mapping)-1, mapping[30000000])
+	dataOf := func(intervals []Interval) []interface{} {
+		var result []interface{}
+		for _, interval := range intervals {
+			result = append(result, interval.Data)
+		}
+		return result
+	}
+	for original, rank := range mapping {
+		assert.ElementsMatch(dataOf(tree.queryIntervals(original)), dataOf(compressed.queryIntervals(rank)))
+		for other, otherRank := range mapping {
+			if original < other {
+				assert.ElementsMatch(dataOf(tree.QueryRange(original, other)),
+					dataOf(compressed.QueryRange(rank, otherRank)))
+			}
+		}
+	}
+	for _, interval := range compressed.intervals() {
+		assert.Less(interval.Start, interval.End)
+	}
+	empty, mapping := NewIntervalTree(0, 10).Compress()
+	assert.Equal(0, empty.Len())
+	assert.Empty(mapping)
+}
+
+func TestShift(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(0, 100)
+	tree.AddInterval(10, 30, "a")
+	tree.AddInterval(20, 60, "b")
+	tree.AddInterval(70, 80, "c")
+	tree.Sort()
+	shifted := tree.Shift(-50)
+	assert.Equal(-50, shifted.min)
+	assert.Equal(50, shifted.max)
+	assert.Equal([]Interval{{-40, -20, "a"}, {-30, 10, "b"}, {20, 30, "c"}}, shifted.IterSorted())
+	assert.ElementsMatch([]interface{}{[]interface{}{-40, -20, "a"}, []interface{}{-30, 10, "b"}}, shifted.Query(-25))
+	assert.Equal([]interface{}{[]interface{}{20, 30, "c"}}, shifted.Query(25))
+	assert.Empty(shifted.Query(75))
+	assert.Equal(3, tree.Len())
+	assert.Equal([]Interval{{10, 30, "a"}, {20, 60, "b"}, {70, 80, "c"}}, tree.IterSorted())
+}
+
+func TestScale(t *testing.T) {
+	assert := assert.New(t)
+	tree := NewIntervalTree(-10, 100)
+	tree.AddInterval(-5, 30, "a")
+	tree.AddInterval(20, 60, "b")
+	tree.AddInterval(70, 80, "c")
+	tree.Sort()
+	scaled, err := tree.Scale(2)
+	assert.NoError(err)
+	assert.Equal(-20, scaled.min)
+	assert.Equal(200, scaled.max)
+	assert.Equal([]Interval{{-10, 60, "a"}, {40, 120, "b"}, {140, 160, "c"}}, scaled.IterSorted())
+	assert.ElementsMatch(tree.Query(25), []interface{}{[]interface{}{-5, 30, "a"}, []interface{}{20, 60, "b"}})
+	assert.ElementsMatch([]interface{}{[]interface{}{-10, 60, "a"}, []interface{}{40, 120, "b"}}, scaled.Query(50))
+	assert.Equal([]interface{}{[]interface{}{40, 120, "b"}}, scaled.Query(60))
+	assert.Empty(scaled.Query(130))
+	_, err = tree.Scale(0)
+	assert.Error(err)
+	_, err = tree.Scale(-2)
+	assert.Error(err)
+	_, err = NewIntervalTree(0, math.MaxInt/2+1).Scale(2)
+	assert.Error(err)
+}
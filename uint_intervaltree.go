@@ -0,0 +1,221 @@
+package gointervaltree
+
+import (
+	"log"
+	"sort"
+)
+
+// UintIntervalTree struct defines data structure for indexing a set of unsigned 64-bit integer intervals,
+// e.g. [start, end), mirroring IntervalTree for coordinates not representable by int.
+type UintIntervalTree struct {
+	min              uint64
+	max              uint64
+	center           uint64
+	singleInterval   *UintInterval
+	split            bool
+	leftSubtree      *UintIntervalTree
+	rightSubtree     *UintIntervalTree
+	midSortedByStart []*UintInterval
+	midSortedByEnd   []*UintInterval
+}
+
+// UintInterval struct defines a single [Start, End) unsigned interval together with its associated data.
+type UintInterval struct {
+	Start uint64
+	End   uint64
+	Data  interface{}
+}
+
+// NewUintIntervalTree method instantiates an instance of UintIntervalTree struct creating a node for keeping
+// intervals. The center is derived as min + (max - min) / 2 so that it never overflows.
+func NewUintIntervalTree(min uint64, max uint64) (tree *UintIntervalTree) {
+	tree = new(UintIntervalTree)
+	tree.min = min
+	tree.max = max
+	if !(tree.min < tree.max) {
+		log.Panic("AssertionError: interval tree start must be numerically less than its end")
+	}
+	tree.center = min + (max-min)/2
+	tree.midSortedByStart = []*UintInterval{}
+	tree.midSortedByEnd = []*UintInterval{}
+	return tree
+}
+
+// AddInterval method adds intervals to the tree without sorting them along the way.
+func (tree *UintIntervalTree) AddInterval(start uint64, end uint64, data interface{}) {
+	if end <= start {
+		return
+	}
+	tree.addInterval(&UintInterval{Start: start, End: end, Data: data})
+}
+
+// addInterval method is a technical method used inside AddInterval for recursive insertion.
+func (tree *UintIntervalTree) addInterval(interval *UintInterval) {
+	if tree.singleInterval == nil && !tree.split {
+		tree.singleInterval = interval
+	} else if tree.split {
+		tree.addIntervalMain(interval)
+	} else {
+		single := tree.singleInterval
+		tree.singleInterval = nil
+		tree.split = true
+		tree.addIntervalMain(single)
+		tree.addIntervalMain(interval)
+	}
+}
+
+// addIntervalMain method is a technical method used inside AddInterval.
+func (tree *UintIntervalTree) addIntervalMain(interval *UintInterval) {
+	if interval.End <= tree.center {
+		if tree.leftSubtree == nil {
+			tree.leftSubtree = NewUintIntervalTree(tree.min, tree.center)
+		}
+		tree.leftSubtree.addInterval(interval)
+	} else if interval.Start > tree.center {
+		if tree.rightSubtree == nil {
+			tree.rightSubtree = NewUintIntervalTree(tree.center, tree.max)
+		}
+		tree.rightSubtree.addInterval(interval)
+	} else {
+		tree.midSortedByStart = append(tree.midSortedByStart, interval)
+		tree.midSortedByEnd = append(tree.midSortedByEnd, interval)
+	}
+}
+
+// Sort method is used to sort intervals within the tree and must be invoked after adding intervals.
+func (tree *UintIntervalTree) Sort() {
+	if !tree.split {
+		return
+	}
+	sort.Slice(tree.midSortedByStart, func(i, j int) bool {
+		return tree.midSortedByStart[i].Start < tree.midSortedByStart[j].Start
+	})
+	sort.Slice(tree.midSortedByEnd, func(i, j int) bool {
+		return tree.midSortedByEnd[i].End > tree.midSortedByEnd[j].End
+	})
+	if tree.leftSubtree != nil {
+		tree.leftSubtree.Sort()
+	}
+	if tree.rightSubtree != nil {
+		tree.rightSubtree.Sort()
+	}
+}
+
+// Query method returns all intervals in the tree which overlap given point, i.e. all intervals,
+// for which (start <= x < end).
+func (tree *UintIntervalTree) Query(x uint64) []UintInterval {
+	var result []UintInterval
+	if !tree.split {
+		if tree.singleInterval != nil && tree.singleInterval.Start <= x && x < tree.singleInterval.End {
+			result = append(result, *tree.singleInterval)
+		}
+		return result
+	} else if x < tree.center {
+		if tree.leftSubtree != nil {
+			result = append(result, tree.leftSubtree.Query(x)...)
+		}
+		for _, element := range tree.midSortedByStart {
+			if element.Start <= x {
+				result = append(result, *element)
+			} else {
+				break
+			}
+		}
+		return result
+	} else {
+		for _, element := range tree.midSortedByEnd {
+			if element.End > x {
+				result = append(result, *element)
+			} else {
+				break
+			}
+		}
+		if tree.rightSubtree != nil {
+			result = append(result, tree.rightSubtree.Query(x)...)
+		}
+		return result
+	}
+}
+
+// QueryRange method returns all intervals in the tree which overlap given range [low, high),
+// i.e. all intervals for which (start < high && low < end).
+func (tree *UintIntervalTree) QueryRange(low uint64, high uint64) []UintInterval {
+	var result []UintInterval
+	if high <= low {
+		return result
+	}
+	if !tree.split {
+		if tree.singleInterval != nil && tree.singleInterval.Start < high && low < tree.singleInterval.End {
+			result = append(result, *tree.singleInterval)
+		}
+		return result
+	}
+	if low <= tree.center && tree.leftSubtree != nil {
+		result = append(result, tree.leftSubtree.QueryRange(low, high)...)
+	}
+	if high <= tree.center {
+		for _, element := range tree.midSortedByStart {
+			if element.Start < high {
+				result = append(result, *element)
+			} else {
+				break
+			}
+		}
+	} else if low > tree.center {
+		for _, element := range tree.midSortedByEnd {
+			if element.End > low {
+				result = append(result, *element)
+			} else {
+				break
+			}
+		}
+	} else {
+		for _, element := range tree.midSortedByStart {
+			result = append(result, *element)
+		}
+	}
+	if high > tree.center && tree.rightSubtree != nil {
+		result = append(result, tree.rightSubtree.QueryRange(low, high)...)
+	}
+	return result
+}
+
+// Len method represents the number of intervals maintained in the tree, zero- or negative-size intervals
+// are not registered.
+func (tree *UintIntervalTree) Len() int {
+	if !tree.split {
+		if tree.singleInterval == nil {
+			return 0
+		}
+		return 1
+	}
+	size := len(tree.midSortedByStart)
+	if tree.leftSubtree != nil {
+		size += tree.leftSubtree.Len()
+	}
+	if tree.rightSubtree != nil {
+		size += tree.rightSubtree.Len()
+	}
+	return size
+}
+
+// Iter method returns a slice of all intervals maintained in the tree.
+func (tree *UintIntervalTree) Iter() []UintInterval {
+	var result []UintInterval
+	if !tree.split {
+		if tree.singleInterval != nil {
+			result = append(result, *tree.singleInterval)
+		}
+		return result
+	}
+	if tree.leftSubtree != nil {
+		result = append(result, tree.leftSubtree.Iter()...)
+	}
+	if tree.rightSubtree != nil {
+		result = append(result, tree.rightSubtree.Iter()...)
+	}
+	for _, element := range tree.midSortedByStart {
+		result = append(result, *element)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package gointervaltree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUintIntervalTree(t *testing.T) {
+	assert := assert.New(t)
+	const top = math.MaxUint64
+	tree := NewUintIntervalTree(0, top)
+	assert.Equal(uint64(math.MaxUint64/2), tree.center)
+	intervals := []UintInterval{{top - 10, top - 1, "a"}, {top - 5, top, "b"}, {1 << 63, top - 3, "c"},
+		{0, 10, "d"}, {top/2 - 1, top/2 + 1, "e"}, {top - 2, top - 1, "f"}}
+	for _, interval := range intervals {
+		tree.AddInterval(interval.Start, interval.End, interval.Data)
+	}
+	tree.AddInterval(top, top, "empty")
+	tree.Sort()
+	assert.Equal(len(intervals), tree.Len())
+	assert.ElementsMatch(intervals, tree.Iter())
+	queryPoints := []uint64{0, 9, 10, top / 2, top/2 + 1, 1 << 63, top - 11, top - 10, top - 5, top - 3, top - 2,
+		top - 1}
+	for _, q := range queryPoints {
+		var expected []UintInterval
+		for _, interval := range intervals {
+			if interval.Start <= q && q < interval.End {
+				expected = append(expected, interval)
+			}
+		}
+		assert.ElementsMatch(expected, tree.Query(q))
+	}
+	for _, low := range queryPoints {
+		for _, high := range queryPoints {
+			var expected []UintInterval
+			if low < high {
+				for _, interval := range intervals {
+					if interval.Start < high && low < interval.End {
+						expected = append(expected, interval)
+					}
+				}
+			}
+			assert.ElementsMatch(expected, tree.QueryRange(low, high))
+		}
+	}
+}